@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kavantix/go-form/auth"
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/pkg/i18n"
+	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/Kavantix/go-form/templates"
+	"github.com/Kavantix/go-form/templates/components"
+	"github.com/a-h/templ"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+)
+
+// apiTokenScopes are the scopes that an api token can be given,
+// the scope of a request is the first segment of its path followed by `:read` or `:write`.
+var apiTokenScopes = []string{
+	"users:read",
+	"users:write",
+	"assignments:read",
+	"assignments:write",
+}
+
+// apiTokenExpiryDays are the number of days an api token can be valid for, 0 means it does not expire
+var apiTokenExpiryDays = []int{30, 90, 365, 0}
+
+// maxApiTokenNameLength is the length of the name column of the api_tokens table
+const maxApiTokenNameLength = 100
+
+var errApiTokenExpired = errors.New("api token is expired")
+
+// bearerToken returns the token of the authorization header when the request uses bearer authentication
+func bearerToken(c echo.Context) (string, bool) {
+	scheme, token, found := strings.Cut(c.Request().Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func authenticateApiToken(c echo.Context, queries *database.Queries, token string) (database.GetApiTokenByHashRow, error) {
+	apiToken, err := queries.GetApiTokenByHash(c.Request().Context(), auth.HashApiToken(token))
+	if err != nil {
+		return apiToken, fmt.Errorf("failed to get api token: %w", err)
+	}
+	if apiToken.ExpiresAt.Valid && time.Now().After(apiToken.ExpiresAt.Time) {
+		return apiToken, errApiTokenExpired
+	}
+	err = queries.TouchApiToken(c.Request().Context(), apiToken.Id)
+	if err != nil {
+		return apiToken, fmt.Errorf("failed to touch api token: %w", err)
+	}
+	return apiToken, nil
+}
+
+// requiredApiTokenScope returns the scope an api token needs to make the current request
+func requiredApiTokenScope(c echo.Context) string {
+	resource, _, _ := strings.Cut(strings.TrimPrefix(c.Path(), "/"), "/")
+	switch c.Request().Method {
+	case "GET", "HEAD":
+		return resource + ":read"
+	default:
+		return resource + ":write"
+	}
+}
+
+func renderAccountApiTokens(c echo.Context, queries *database.Queries, code int, form templates.ApiTokenForm, toasts ...components.ToastConfig) error {
+	tokens, err := queries.GetUserApiTokens(c.Request().Context(), authenticatedUserId(c))
+	if err != nil {
+		return fmt.Errorf("failed to get api tokens: %w", err)
+	}
+	form.Scopes = apiTokenScopes
+	form.ExpiryDays = apiTokenExpiryDays
+	templatesToRender := []templ.Component{
+		templates.AccountApiTokens(tokens, form),
+	}
+	for _, toast := range toasts {
+		templatesToRender = append(templatesToRender, components.Toast(toast))
+	}
+	return template(c, code, templatesToRender...)
+}
+
+func HandleAccountApiTokens(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return renderAccountApiTokens(c, queries, 200, templates.ApiTokenForm{})
+	}
+}
+
+func HandleCreateApiToken(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		values, err := c.FormParams()
+		if err != nil {
+			return c.String(400, "invalid form")
+		}
+		name := strings.TrimSpace(values.Get("name"))
+		scopes := values["scopes"]
+		expiryDays, err := strconv.Atoi(values.Get("expires_in"))
+		if err != nil || !slices.Contains(apiTokenExpiryDays, expiryDays) {
+			return c.String(400, "invalid expiry")
+		}
+		validationErrors := map[string]string{}
+		if name == "" {
+			validationErrors["name"] = i18n.T(c.Request().Context(), "Name is required")
+		} else if len(name) > maxApiTokenNameLength {
+			validationErrors["name"] = i18n.T(c.Request().Context(), "Name can be at most %d characters", maxApiTokenNameLength)
+		}
+		if len(scopes) == 0 {
+			validationErrors["scopes"] = i18n.T(c.Request().Context(), "Select at least one scope")
+		}
+		for _, scope := range scopes {
+			if !slices.Contains(apiTokenScopes, scope) {
+				validationErrors["scopes"] = i18n.T(c.Request().Context(), "Unknown scope %s", scope)
+			}
+		}
+		if len(validationErrors) > 0 {
+			return renderAccountApiTokens(c, queries, 422, templates.ApiTokenForm{
+				Name:             name,
+				SelectedScopes:   scopes,
+				ValidationErrors: validationErrors,
+			})
+		}
+		token, tokenHash, err := auth.GenerateApiToken()
+		if err != nil {
+			return err
+		}
+		expiresAt := pgtype.Timestamp{}
+		if expiryDays > 0 {
+			expiresAt = pgtype.Timestamp{Time: time.Now().AddDate(0, 0, expiryDays), Valid: true}
+		}
+		_, err = queries.InsertApiToken(c.Request().Context(), database.InsertApiTokenParams{
+			UserID:    authenticatedUserId(c),
+			Name:      name,
+			TokenHash: tokenHash,
+			Scopes:    scopes,
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save api token: %w", err)
+		}
+		logger.EchoInfo(c, "Created api token", slog.Any("scopes", scopes))
+		// The token is only rendered here, so non-js requests are not redirected
+		return renderAccountApiTokens(c, queries, 200, templates.ApiTokenForm{
+			CreatedToken: token,
+		})
+	}
+}
+
+func HandleRevokeApiToken(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.String(400, "invalid id")
+		}
+		revoked, err := queries.DeleteUserApiToken(c.Request().Context(), int32(id), authenticatedUserId(c))
+		if err != nil {
+			return fmt.Errorf("failed to revoke api token: %w", err)
+		}
+		if revoked == 0 {
+			return template(c, 404, templates.NotFound("/account/tokens"))
+		}
+		logger.EchoInfo(c, "Revoked api token", slog.Int("token", id))
+		if !isHtmx(c) {
+			return c.Redirect(303, "/account/tokens")
+		}
+		return renderAccountApiTokens(c, queries, 200, templates.ApiTokenForm{}, components.ToastConfig{
+			Message: i18n.T(c.Request().Context(), "Api token revoked"),
+			Variant: components.ToastSuccess,
+		})
+	}
+}
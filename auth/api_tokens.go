@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// apiTokenPrefix makes api tokens recognizable, for example for secret scanners
+const apiTokenPrefix = "gf_"
+
+// GenerateApiToken returns a new random api token and the hash under which it is stored
+func GenerateApiToken() (token, hash string, err error) {
+	buffer := make([]byte, 32)
+	_, err = rand.Read(buffer)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate api token: %w", err)
+	}
+	token = apiTokenPrefix + base64.RawURLEncoding.EncodeToString(buffer)
+	return token, HashApiToken(token), nil
+}
+
+// HashApiToken returns the hash of an api token, the token itself is only shown once when it is created
+func HashApiToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
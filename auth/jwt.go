@@ -22,7 +22,17 @@ var jwtParser = jwt.NewParser(
 	jwt.WithIssuer("go-form"),
 )
 
+// keyFunc returns the public key that signed the token by its `kid` header,
+// tokens without the header were signed before the keyring and are verified with the active key.
 func keyFunc(t *jwt.Token) (any, error) {
+	keyId, ok := t.Header["kid"].(string)
+	if !ok {
+		keyId = activeKeyId
+	}
+	publicKey, ok := publicKeys[keyId]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key `%s`", keyId)
+	}
 	return publicKey, nil
 }
 
@@ -39,8 +49,12 @@ func ParseJwt(tokenString string) (jwt.MapClaims, error) {
 }
 
 type JwtOptions struct {
-	Audience    string
-	Subject     string
+	// Id is used as the `jti` claim, it allows the token to be revoked
+	Id       string
+	Audience string
+	Subject  string
+	// Actor is used as the `act` claim when someone else acts on behalf of the subject
+	Actor       string
 	ValidFor    time.Duration
 	ExtraClaims map[string]string
 }
@@ -51,12 +65,18 @@ func (o *JwtOptions) claims() jwt.MapClaims {
 		"iat": time.Now().Unix(),
 	}
 
+	if o.Id != "" {
+		claims["jti"] = o.Id
+	}
 	if o.Audience != "" {
 		claims["aud"] = o.Audience
 	}
 	if o.Subject != "" {
 		claims["sub"] = o.Subject
 	}
+	if o.Actor != "" {
+		claims["act"] = map[string]string{"sub": o.Actor}
+	}
 	if o.ExtraClaims != nil {
 		claims["extra"] = o.ExtraClaims
 	}
@@ -77,9 +97,20 @@ func CreateJwt(options *JwtOptions) (string, error) {
 		claims = jwt.MapClaims{}
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = activeKeyId
 	result, err := token.SignedString(privateKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign jwt: %w", err)
 	}
 	return result, nil
 }
+
+// ActorSubject returns the subject of the `act` claim, or an empty string when no one acts on behalf of the subject
+func ActorSubject(claims jwt.MapClaims) string {
+	actor, ok := claims["act"].(map[string]any)
+	if !ok {
+		return ""
+	}
+	subject, _ := actor["sub"].(string)
+	return subject
+}
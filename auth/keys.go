@@ -3,38 +3,197 @@ package auth
 import (
 	"crypto/ed25519"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
 var (
 	privateKey ed25519.PrivateKey
-	publicKey  ed25519.PublicKey
+	// activeKeyId is the id of privateKey, it is set as `kid` header of the tokens it signs
+	activeKeyId string
+	// publicKeys are all keys that tokens are accepted from by their id,
+	// including retired keys so tokens that were signed before rotating stay valid.
+	publicKeys map[string]ed25519.PublicKey
 )
 
-func LoadKeys(privPath, pubPath string) error {
+// KeyringKey is a key in the keyring directory
+type KeyringKey struct {
+	Id        string
+	PublicKey ed25519.PublicKey
+	// Retired keys have no private key anymore, they can only be used to verify tokens
+	Retired bool
+}
 
-	privKeyBytes, err := os.ReadFile(privPath)
+// ReadKeyring returns the keys in dir, each key is stored as `<id>.pub` with its private key in `<id>.priv`.
+func ReadKeyring(dir string) ([]KeyringKey, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return fmt.Errorf("cannot read private key: %w", err)
+		return nil, fmt.Errorf("cannot read keyring: %w", err)
+	}
+	keys := []KeyringKey{}
+	for _, entry := range entries {
+		id, isPublicKey := strings.CutSuffix(entry.Name(), ".pub")
+		if !isPublicKey || entry.IsDir() {
+			continue
+		}
+		publicKey, err := readKey(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read public key `%s`: %w", id, err)
+		}
+		_, err = os.Stat(filepath.Join(dir, id+".priv"))
+		keys = append(keys, KeyringKey{
+			Id:        id,
+			PublicKey: ed25519.PublicKey(publicKey),
+			Retired:   errors.Is(err, fs.ErrNotExist),
+		})
 	}
-	privKeyBytes, err = Base64Decode(privKeyBytes)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Id < keys[j].Id })
+	return keys, nil
+}
+
+// LoadKeyring loads the keys in dir, tokens are signed with the key with activeId.
+func LoadKeyring(dir, activeId string) error {
+	keys, err := ReadKeyring(dir)
 	if err != nil {
-		return fmt.Errorf("cannot decode private key: %w", err)
+		return err
+	}
+	loadedPublicKeys := map[string]ed25519.PublicKey{}
+	for _, key := range keys {
+		loadedPublicKeys[key.Id] = key.PublicKey
+	}
+	if _, ok := loadedPublicKeys[activeId]; !ok {
+		return fmt.Errorf("signing key `%s` is not in the keyring", activeId)
+	}
+	privKeyBytes, err := readKey(filepath.Join(dir, activeId+".priv"))
+	if err != nil {
+		return fmt.Errorf("cannot read private key of signing key `%s`: %w", activeId, err)
 	}
 	privateKey = ed25519.PrivateKey(privKeyBytes)
-	pubKeyBytes, err := os.ReadFile(pubPath)
+	activeKeyId = activeId
+	publicKeys = loadedPublicKeys
+	return nil
+}
+
+// AddKey generates a new key with id in the keyring in dir
+func AddKey(dir, id string, overwrite bool) error {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return fmt.Errorf("invalid key id `%s`", id)
+	}
+	privPath := filepath.Join(dir, id+".priv")
+	pubPath := filepath.Join(dir, id+".pub")
+	if !overwrite {
+		_, privErr := os.Stat(privPath)
+		_, pubErr := os.Stat(pubPath)
+		if !errors.Is(privErr, fs.ErrNotExist) || !errors.Is(pubErr, fs.ErrNotExist) {
+			return fmt.Errorf("key `%s` already exists", id)
+		}
+	}
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create keyring: %w", err)
+	}
+	err = os.WriteFile(privPath, Base64Encode(privateKey), 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+	err = os.WriteFile(pubPath, Base64Encode(publicKey), 0644)
 	if err != nil {
-		return fmt.Errorf("cannot read public key: %w", err)
+		return fmt.Errorf("failed to write public key: %w", err)
 	}
+	return nil
+}
+
+var (
+	ErrUnknownKey = errors.New("key is not in the keyring")
+	// ErrOnlyActiveKey is returned when retiring the last key that can sign tokens
+	ErrOnlyActiveKey = errors.New("key is the only key that can sign tokens")
+)
+
+// RetireKey removes the private key of id, so it can no longer sign tokens.
+// The public key is kept to verify tokens that were signed with it, unless removePublicKey is set.
+// The only key that can still sign tokens cannot be retired, so a new key has to be added first.
+func RetireKey(dir, id string, removePublicKey bool) error {
+	keys, err := ReadKeyring(dir)
 	if err != nil {
-		return fmt.Errorf("cannot decode public key: %w", err)
+		return err
+	}
+	var key *KeyringKey
+	canSign := 0
+	for i := range keys {
+		if keys[i].Id == id {
+			key = &keys[i]
+		}
+		if !keys[i].Retired {
+			canSign++
+		}
+	}
+	if key == nil {
+		return fmt.Errorf("%w: `%s`", ErrUnknownKey, id)
+	}
+	if !key.Retired && canSign == 1 {
+		return fmt.Errorf("%w: `%s`", ErrOnlyActiveKey, id)
+	}
+	err = os.Remove(filepath.Join(dir, id+".priv"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove private key: %w", err)
+	}
+	if removePublicKey {
+		err = os.Remove(filepath.Join(dir, id+".pub"))
+		if err != nil {
+			return fmt.Errorf("failed to remove public key: %w", err)
+		}
 	}
-	pubKeyBytes, err = Base64Decode(pubKeyBytes)
-	publicKey = ed25519.PublicKey(pubKeyBytes)
 	return nil
 }
 
+// Jwk is a public key in JSON Web Key format
+type Jwk struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyId     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// Jwks returns the public keys that tokens are accepted from, so others can verify our tokens
+func Jwks() []Jwk {
+	keys := []Jwk{}
+	for id, publicKey := range publicKeys {
+		keys = append(keys, Jwk{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(publicKey),
+			KeyId:     id,
+			Use:       "sig",
+			Algorithm: "EdDSA",
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].KeyId < keys[j].KeyId })
+	return keys
+}
+
+func readKey(path string) ([]byte, error) {
+	keyBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keyBytes, err = Base64Decode(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode key: %w", err)
+	}
+	return keyBytes, nil
+}
+
 func Base64Decode(bytes []byte) ([]byte, error) {
 	dst := make([]byte, base64.StdEncoding.DecodedLen(len(bytes)))
 	n, err := base64.StdEncoding.Decode(dst, bytes)
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRetireKey(t *testing.T) {
+	dir := t.TempDir()
+	for _, id := range []string{"old", "new"} {
+		err := AddKey(dir, id, false)
+		if err != nil {
+			t.Fatalf("failed to add key %s: %s", id, err)
+		}
+	}
+
+	err := RetireKey(dir, "unknown", false)
+	if !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey when retiring an unknown key, got %v", err)
+	}
+	err = RetireKey(dir, "../old", false)
+	if !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey when retiring a key outside the keyring, got %v", err)
+	}
+
+	err = RetireKey(dir, "old", false)
+	if err != nil {
+		t.Fatalf("failed to retire key: %s", err)
+	}
+	err = RetireKey(dir, "new", false)
+	if !errors.Is(err, ErrOnlyActiveKey) {
+		t.Errorf("expected ErrOnlyActiveKey when retiring the last key that can sign, got %v", err)
+	}
+	// The public key of a retired key can still be removed
+	err = RetireKey(dir, "old", true)
+	if err != nil {
+		t.Fatalf("failed to remove retired key: %s", err)
+	}
+
+	keys, err := ReadKeyring(dir)
+	if err != nil {
+		t.Fatalf("failed to read keyring: %s", err)
+	}
+	if len(keys) != 1 || keys[0].Id != "new" || keys[0].Retired {
+		t.Errorf("keyring is %+v, expected only the active key `new`", keys)
+	}
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// oidcLoginValidFor is how long the identity provider can take to redirect back after starting a login
+const oidcLoginValidFor = time.Minute * 10
+
+type OidcConfig struct {
+	// Issuer is the url of the identity provider, its configuration is discovered from there
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	// RedirectUrl is the url of the callback that the identity provider redirects to
+	RedirectUrl string
+	// AllowMissingEmailVerified accepts id tokens without an email_verified claim,
+	// only enable it for identity providers that verify every email but do not share the claim.
+	AllowMissingEmailVerified bool
+}
+
+// OidcProvider logs users in with an OpenID Connect identity provider
+// using the authorization code flow with PKCE.
+type OidcProvider struct {
+	config OidcConfig
+
+	mutex    sync.Mutex
+	provider *oidc.Provider
+}
+
+// OidcIdentity is the user that the identity provider logged in
+type OidcIdentity struct {
+	Subject string
+	Email   string
+	Name    string
+	// BirthDate is zero when the identity provider does not share it
+	BirthDate time.Time
+}
+
+func NewOidcProvider(config OidcConfig) *OidcProvider {
+	return &OidcProvider{config: config}
+}
+
+// discover returns the configuration of the identity provider,
+// it is fetched on first use so the identity provider being down does not stop the server from starting.
+func (p *OidcProvider) discover(ctx context.Context) (*oidc.Provider, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.provider == nil {
+		provider, err := oidc.NewProvider(ctx, p.config.Issuer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover identity provider: %w", err)
+		}
+		p.provider = provider
+	}
+	return p.provider, nil
+}
+
+func (p *OidcProvider) oauth2Config(provider *oidc.Provider) oauth2.Config {
+	return oauth2.Config{
+		ClientID:     p.config.ClientId,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectUrl,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+	}
+}
+
+// BeginLogin returns the url of the identity provider to redirect to,
+// and the state that is needed to finish the login in the callback.
+func (p *OidcProvider) BeginLogin(ctx context.Context) (authUrl string, state string, err error) {
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	stateParam, err := GenerateOTP(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := GenerateOTP(32)
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+	state, err = CreateJwt(&JwtOptions{
+		Audience: "oidc",
+		ValidFor: oidcLoginValidFor,
+		ExtraClaims: map[string]string{
+			"state":    stateParam,
+			"nonce":    nonce,
+			"verifier": verifier,
+		},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	config := p.oauth2Config(provider)
+	authUrl = config.AuthCodeURL(stateParam, oauth2.S256ChallengeOption(verifier), oidc.Nonce(nonce))
+	return authUrl, state, nil
+}
+
+// FinishLogin exchanges the code that the identity provider redirected back with for an ID token,
+// and returns the identity in it after it is verified.
+func (p *OidcProvider) FinishLogin(ctx context.Context, state, stateParam, code string) (OidcIdentity, error) {
+	claims, err := ParseJwt(state)
+	if err != nil {
+		return OidcIdentity{}, err
+	}
+	extra, _ := claims["extra"].(map[string]any)
+	expectedState, _ := extra["state"].(string)
+	nonce, _ := extra["nonce"].(string)
+	verifier, _ := extra["verifier"].(string)
+	if claims["aud"] != "oidc" || expectedState == "" || nonce == "" || verifier == "" {
+		return OidcIdentity{}, errors.New("invalid login state")
+	}
+	if stateParam != expectedState {
+		return OidcIdentity{}, errors.New("state does not match")
+	}
+	provider, err := p.discover(ctx)
+	if err != nil {
+		return OidcIdentity{}, err
+	}
+	config := p.oauth2Config(provider)
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return OidcIdentity{}, fmt.Errorf("failed to exchange code: %w", err)
+	}
+	rawIdToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return OidcIdentity{}, errors.New("no id token in response")
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.config.ClientId}).Verify(ctx, rawIdToken)
+	if err != nil {
+		return OidcIdentity{}, fmt.Errorf("invalid id token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return OidcIdentity{}, errors.New("nonce does not match")
+	}
+	var idClaims struct {
+		Email         string `json:"email"`
+		EmailVerified *bool  `json:"email_verified"`
+		Name          string `json:"name"`
+		BirthDate     string `json:"birthdate"`
+	}
+	err = idToken.Claims(&idClaims)
+	if err != nil {
+		return OidcIdentity{}, fmt.Errorf("invalid id token claims: %w", err)
+	}
+	if idClaims.Email == "" {
+		return OidcIdentity{}, errors.New("id token has no email")
+	}
+	// Users are matched on their email, so an unverified email could log in to the account of someone else
+	if idClaims.EmailVerified == nil && !p.config.AllowMissingEmailVerified {
+		return OidcIdentity{}, errors.New("id token has no email_verified claim")
+	}
+	if idClaims.EmailVerified != nil && !*idClaims.EmailVerified {
+		return OidcIdentity{}, errors.New("email is not verified")
+	}
+	identity := OidcIdentity{
+		Subject: idToken.Subject,
+		Email:   idClaims.Email,
+		Name:    idClaims.Name,
+	}
+	if idClaims.BirthDate != "" {
+		identity.BirthDate, _ = time.Parse("2006-01-02", idClaims.BirthDate)
+	}
+	return identity, nil
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kavantix/go-form/auth/oidctest"
+)
+
+func setupOidc(t *testing.T, config OidcConfig) (*OidcProvider, *oidctest.Provider) {
+	t.Helper()
+	dir := t.TempDir()
+	err := AddKey(dir, "test", false)
+	if err != nil {
+		t.Fatalf("failed to add signing key: %s", err)
+	}
+	err = LoadKeyring(dir, "test")
+	if err != nil {
+		t.Fatalf("failed to load signing key: %s", err)
+	}
+	mock := oidctest.NewProvider(t, "go-form")
+	config.Issuer = mock.URL
+	config.ClientId = mock.ClientId
+	config.RedirectUrl = testOrigin + "/login/oidc/callback"
+	return NewOidcProvider(config), mock
+}
+
+// loginWithOidc logs in at mock with an id token with claims,
+// and returns the login state and the state param and code that the callback receives.
+func loginWithOidc(t *testing.T, provider *OidcProvider, mock *oidctest.Provider, claims map[string]any) (state, stateParam, code string) {
+	t.Helper()
+	authUrl, state, err := provider.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatalf("failed to begin login: %s", err)
+	}
+	if !strings.HasPrefix(authUrl, mock.URL+"/authorize?") {
+		t.Fatalf("auth url `%s` is not at the identity provider", authUrl)
+	}
+	parsed, err := url.Parse(authUrl)
+	if err != nil {
+		t.Fatalf("invalid auth url: %s", err)
+	}
+	return state, parsed.Query().Get("state"), mock.Authorize(t, authUrl, claims)
+}
+
+func verifiedClaims() map[string]any {
+	return map[string]any{
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"name":           "Jane Doe",
+		"birthdate":      "1990-05-17",
+	}
+}
+
+func TestOidcLogin(t *testing.T) {
+	provider, mock := setupOidc(t, OidcConfig{})
+	state, stateParam, code := loginWithOidc(t, provider, mock, verifiedClaims())
+
+	identity, err := provider.FinishLogin(context.Background(), state, stateParam, code)
+	if err != nil {
+		t.Fatalf("failed to finish login: %s", err)
+	}
+	expected := OidcIdentity{
+		Subject:   "subject",
+		Email:     "jane@example.com",
+		Name:      "Jane Doe",
+		BirthDate: time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC),
+	}
+	if identity != expected {
+		t.Errorf("identity is %+v, expected %+v", identity, expected)
+	}
+
+	// The code was exchanged, so the callback cannot be replayed
+	_, err = provider.FinishLogin(context.Background(), state, stateParam, code)
+	if err == nil {
+		t.Error("finished a login twice with the same code")
+	}
+}
+
+func TestOidcLoginRejectsTamperedState(t *testing.T) {
+	provider, mock := setupOidc(t, OidcConfig{})
+
+	t.Run("state param", func(t *testing.T) {
+		state, _, code := loginWithOidc(t, provider, mock, verifiedClaims())
+		_, otherStateParam, _ := loginWithOidc(t, provider, mock, verifiedClaims())
+		_, err := provider.FinishLogin(context.Background(), state, otherStateParam, code)
+		if err == nil || err.Error() != "state does not match" {
+			t.Fatalf("expected a state mismatch, got %v", err)
+		}
+	})
+
+	t.Run("state cookie", func(t *testing.T) {
+		state, _, code := loginWithOidc(t, provider, mock, verifiedClaims())
+		// The state of the cookie is changed to the one of another login without signing it again
+		header, rest, _ := strings.Cut(state, ".")
+		payload, signature, _ := strings.Cut(rest, ".")
+		decoded, err := base64.RawURLEncoding.DecodeString(payload)
+		if err != nil {
+			t.Fatalf("invalid state payload: %s", err)
+		}
+		var claims map[string]any
+		err = json.Unmarshal(decoded, &claims)
+		if err != nil {
+			t.Fatalf("invalid state claims: %s", err)
+		}
+		forgedStateParam := "forged"
+		claims["extra"].(map[string]any)["state"] = forgedStateParam
+		encoded, err := json.Marshal(claims)
+		if err != nil {
+			t.Fatalf("failed to encode state claims: %s", err)
+		}
+		tampered := header + "." + base64.RawURLEncoding.EncodeToString(encoded) + "." + signature
+		_, err = provider.FinishLogin(context.Background(), tampered, forgedStateParam, code)
+		if err == nil {
+			t.Fatal("finished a login with a tampered state")
+		}
+	})
+}
+
+func TestOidcLoginRejectsMismatchedNonce(t *testing.T) {
+	provider, mock := setupOidc(t, OidcConfig{})
+	claims := verifiedClaims()
+	claims["nonce"] = "replayed"
+	state, stateParam, code := loginWithOidc(t, provider, mock, claims)
+
+	_, err := provider.FinishLogin(context.Background(), state, stateParam, code)
+	if err == nil || err.Error() != "nonce does not match" {
+		t.Fatalf("expected a nonce mismatch, got %v", err)
+	}
+}
+
+func TestOidcLoginRejectsUnverifiedEmail(t *testing.T) {
+	t.Run("unverified", func(t *testing.T) {
+		provider, mock := setupOidc(t, OidcConfig{AllowMissingEmailVerified: true})
+		claims := verifiedClaims()
+		claims["email_verified"] = false
+		state, stateParam, code := loginWithOidc(t, provider, mock, claims)
+		_, err := provider.FinishLogin(context.Background(), state, stateParam, code)
+		if err == nil || err.Error() != "email is not verified" {
+			t.Fatalf("expected an unverified email, got %v", err)
+		}
+	})
+
+	t.Run("missing claim", func(t *testing.T) {
+		provider, mock := setupOidc(t, OidcConfig{})
+		claims := verifiedClaims()
+		delete(claims, "email_verified")
+		state, stateParam, code := loginWithOidc(t, provider, mock, claims)
+		_, err := provider.FinishLogin(context.Background(), state, stateParam, code)
+		if err == nil || err.Error() != "id token has no email_verified claim" {
+			t.Fatalf("expected a missing email_verified claim, got %v", err)
+		}
+	})
+
+	t.Run("missing claim allowed", func(t *testing.T) {
+		provider, mock := setupOidc(t, OidcConfig{AllowMissingEmailVerified: true})
+		claims := verifiedClaims()
+		delete(claims, "email_verified")
+		state, stateParam, code := loginWithOidc(t, provider, mock, claims)
+		_, err := provider.FinishLogin(context.Background(), state, stateParam, code)
+		if err != nil {
+			t.Fatalf("failed to finish login: %s", err)
+		}
+	})
+}
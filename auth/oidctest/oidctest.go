@@ -0,0 +1,174 @@
+// oidctest runs a mock OpenID Connect identity provider for tests,
+// it serves the discovery document, the keys and the token endpoint of the authorization code flow with PKCE.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyId = "oidctest"
+
+type authorization struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+// Provider is a mock identity provider, its issuer is the url of the server
+type Provider struct {
+	*httptest.Server
+	ClientId string
+
+	key   *rsa.PrivateKey
+	mutex sync.Mutex
+	codes map[string]authorization
+}
+
+// NewProvider starts a provider for clientId that is closed when the test finishes
+func NewProvider(t *testing.T, clientId string) *Provider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	p := &Provider{
+		ClientId: clientId,
+		key:      key,
+		codes:    map[string]authorization{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.handleDiscovery)
+	mux.HandleFunc("/jwks", p.handleJwks)
+	mux.HandleFunc("/token", p.handleToken)
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// Authorize logs in at authUrl like a user would and returns the code to redirect back with.
+// The id token contains the nonce of authUrl and claims, claims override the defaults.
+func (p *Provider) Authorize(t *testing.T, authUrl string, claims map[string]any) string {
+	t.Helper()
+	parsed, err := url.Parse(authUrl)
+	if err != nil {
+		t.Fatalf("invalid auth url: %s", err)
+	}
+	query := parsed.Query()
+	if query.Get("client_id") != p.ClientId {
+		t.Fatalf("auth url is for client `%s`, expected `%s`", query.Get("client_id"), p.ClientId)
+	}
+	if query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("auth url has no S256 code challenge")
+	}
+	now := time.Now()
+	idClaims := jwt.MapClaims{
+		"iss":   p.URL,
+		"aud":   p.ClientId,
+		"sub":   "subject",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Minute).Unix(),
+		"nonce": query.Get("nonce"),
+	}
+	for name, value := range claims {
+		idClaims[name] = value
+	}
+	code := randomString(t)
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.codes[code] = authorization{challenge: query.Get("code_challenge"), claims: idClaims}
+	return code
+}
+
+func randomString(t *testing.T) string {
+	t.Helper()
+	random := make([]byte, 16)
+	_, err := rand.Read(random)
+	if err != nil {
+		t.Fatalf("failed to generate code: %s", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(random)
+}
+
+func writeJson(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func (p *Provider) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, 200, map[string]any{
+		"issuer":                                p.URL,
+		"authorization_endpoint":                p.URL + "/authorize",
+		"token_endpoint":                        p.URL + "/token",
+		"jwks_uri":                              p.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *Provider) handleJwks(w http.ResponseWriter, r *http.Request) {
+	encode := base64.RawURLEncoding.EncodeToString
+	writeJson(w, 200, map[string]any{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": keyId,
+				"alg": "RS256",
+				"use": "sig",
+				"n":   encode(p.key.N.Bytes()),
+				"e":   encode(big.NewInt(int64(p.key.E)).Bytes()),
+			},
+		},
+	})
+}
+
+func (p *Provider) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJson(w, 405, map[string]string{"error": "invalid_request"})
+		return
+	}
+	clientId, _, ok := r.BasicAuth()
+	if !ok {
+		clientId = r.FormValue("client_id")
+	}
+	if clientId != p.ClientId || r.FormValue("grant_type") != "authorization_code" {
+		writeJson(w, 401, map[string]string{"error": "invalid_client"})
+		return
+	}
+	// Codes can only be exchanged once
+	p.mutex.Lock()
+	authorization, ok := p.codes[r.FormValue("code")]
+	delete(p.codes, r.FormValue("code"))
+	p.mutex.Unlock()
+	verifierHash := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(verifierHash[:]) != authorization.challenge {
+		writeJson(w, 400, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, authorization.claims)
+	token.Header["kid"] = keyId
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		writeJson(w, 500, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJson(w, 200, map[string]any{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
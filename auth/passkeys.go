@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// passkeyCeremonyValidFor is how long a passkey registration or login can take
+const passkeyCeremonyValidFor = time.Minute * 5
+
+var webAuthn *webauthn.WebAuthn
+
+// ErrPasskeyCloned is returned when the sign count of a passkey did not increase,
+// which means the passkey was probably copied to another authenticator.
+var ErrPasskeyCloned = errors.New("passkey is probably cloned")
+
+// InitPasskeys configures the relying party that passkeys are registered for,
+// rpId is the domain of the site and origins are the urls it is served from.
+func InitPasskeys(rpId string, origins []string) error {
+	var err error
+	webAuthn, err = webauthn.New(&webauthn.Config{
+		RPID:          rpId,
+		RPDisplayName: "go-form",
+		RPOrigins:     origins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login: webauthn.TimeoutConfig{
+				Enforce: true,
+				Timeout: passkeyCeremonyValidFor,
+			},
+			Registration: webauthn.TimeoutConfig{
+				Enforce: true,
+				Timeout: passkeyCeremonyValidFor,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("invalid passkey config: %w", err)
+	}
+	return nil
+}
+
+// PasskeyUser is a user with the passkeys that are registered for it
+type PasskeyUser struct {
+	Id          int32
+	Name        string
+	Email       string
+	Credentials []webauthn.Credential
+}
+
+func (u PasskeyUser) WebAuthnID() []byte {
+	return []byte(strconv.Itoa(int(u.Id)))
+}
+
+func (u PasskeyUser) WebAuthnName() string {
+	return u.Email
+}
+
+func (u PasskeyUser) WebAuthnDisplayName() string {
+	return u.Name
+}
+
+func (u PasskeyUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.Credentials
+}
+
+func (u PasskeyUser) WebAuthnIcon() string {
+	return ""
+}
+
+// PasskeyUserId returns the id of the user that a passkey with userHandle was registered for
+func PasskeyUserId(userHandle []byte) (int32, error) {
+	userId, err := strconv.Atoi(string(userHandle))
+	if err != nil {
+		return 0, fmt.Errorf("invalid user handle: %w", err)
+	}
+	return int32(userId), nil
+}
+
+// BeginPasskeyRegistration returns the options for the browser to create a new passkey for user,
+// and the state that is needed to finish the registration.
+func BeginPasskeyRegistration(user PasskeyUser) (*protocol.CredentialCreation, string, error) {
+	excluded := []protocol.CredentialDescriptor{}
+	for _, credential := range user.Credentials {
+		excluded = append(excluded, credential.Descriptor())
+	}
+	options, session, err := webAuthn.BeginRegistration(user, webauthn.WithExclusions(excluded))
+	if err != nil {
+		return nil, "", err
+	}
+	state, err := encodePasskeySession("passkey_registration", session)
+	if err != nil {
+		return nil, "", err
+	}
+	return options, state, nil
+}
+
+// FinishPasskeyRegistration verifies the passkey that the browser created in request
+func FinishPasskeyRegistration(user PasskeyUser, state string, request *http.Request) (*webauthn.Credential, error) {
+	session, err := decodePasskeySession("passkey_registration", state)
+	if err != nil {
+		return nil, err
+	}
+	return webAuthn.FinishRegistration(user, session, request)
+}
+
+// BeginPasskeyLogin returns the options for the browser to sign in with any passkey of the site,
+// and the state that is needed to finish the login.
+func BeginPasskeyLogin() (*protocol.CredentialAssertion, string, error) {
+	options, session, err := webAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, "", err
+	}
+	state, err := encodePasskeySession("passkey_login", session)
+	if err != nil {
+		return nil, "", err
+	}
+	return options, state, nil
+}
+
+// FinishPasskeyLogin verifies the assertion in request,
+// findUser is called with the user handle of the passkey that was used.
+// It returns ErrPasskeyCloned when the sign count shows that the passkey was cloned.
+func FinishPasskeyLogin(state string, request *http.Request, findUser func(userHandle []byte) (PasskeyUser, error)) (PasskeyUser, *webauthn.Credential, error) {
+	session, err := decodePasskeySession("passkey_login", state)
+	if err != nil {
+		return PasskeyUser{}, nil, err
+	}
+	var user PasskeyUser
+	credential, err := webAuthn.FinishDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		user, err = findUser(userHandle)
+		return user, err
+	}, session, request)
+	if err != nil {
+		return PasskeyUser{}, nil, err
+	}
+	if credential.Authenticator.CloneWarning {
+		return user, nil, ErrPasskeyCloned
+	}
+	return user, credential, nil
+}
+
+// encodePasskeySession stores the session of a passkey ceremony in a signed token,
+// callers keep the token on the server and delete it when the ceremony is finished so it cannot be replayed.
+func encodePasskeySession(audience string, session *webauthn.SessionData) (string, error) {
+	encodedSession, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+	return CreateJwt(&JwtOptions{
+		Audience: audience,
+		ValidFor: passkeyCeremonyValidFor,
+		ExtraClaims: map[string]string{
+			"session": string(encodedSession),
+		},
+	})
+}
+
+func decodePasskeySession(audience, state string) (webauthn.SessionData, error) {
+	session := webauthn.SessionData{}
+	claims, err := ParseJwt(state)
+	if err != nil {
+		return session, err
+	}
+	if claims["aud"] != audience {
+		return session, errors.New("invalid audience")
+	}
+	extra, _ := claims["extra"].(map[string]any)
+	encodedSession, _ := extra["session"].(string)
+	err = json.Unmarshal([]byte(encodedSession), &session)
+	if err != nil {
+		return session, fmt.Errorf("invalid passkey session: %w", err)
+	}
+	return session, nil
+}
@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+const (
+	testRpId   = "go-form.test"
+	testOrigin = "https://go-form.test"
+)
+
+// softwareAuthenticator creates and uses a single passkey like a browser would
+type softwareAuthenticator struct {
+	credentialId []byte
+	userHandle   []byte
+	key          *ecdsa.PrivateKey
+	signCount    uint32
+}
+
+func newSoftwareAuthenticator(t *testing.T) *softwareAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	credentialId := make([]byte, 16)
+	_, err = rand.Read(credentialId)
+	if err != nil {
+		t.Fatalf("failed to generate credential id: %s", err)
+	}
+	return &softwareAuthenticator{credentialId: credentialId, key: key}
+}
+
+func encodeBase64Url(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (a *softwareAuthenticator) clientData(t *testing.T, ceremony string, challenge protocol.URLEncodedBase64) []byte {
+	t.Helper()
+	clientData, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": encodeBase64Url(challenge),
+		"origin":    testOrigin,
+	})
+	if err != nil {
+		t.Fatalf("failed to encode client data: %s", err)
+	}
+	return clientData
+}
+
+// authenticatorData returns the authenticator data with the user present and verified,
+// attestedCredential is appended when it is set.
+func (a *softwareAuthenticator) authenticatorData(flags protocol.AuthenticatorFlags, attestedCredential []byte) []byte {
+	rpIdHash := sha256.Sum256([]byte(testRpId))
+	data := append([]byte{}, rpIdHash[:]...)
+	data = append(data, byte(protocol.FlagUserPresent|protocol.FlagUserVerified|flags))
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attestedCredential...)
+}
+
+// create answers the options of a registration with a new passkey and a `none` attestation
+func (a *softwareAuthenticator) create(t *testing.T, options *protocol.CredentialCreation) *http.Request {
+	t.Helper()
+	a.userHandle = options.Response.User.ID.(protocol.URLEncodedBase64)
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatalf("failed to encode public key: %s", err)
+	}
+	attestedCredential := make([]byte, 16) // aaguid
+	attestedCredential = binary.BigEndian.AppendUint16(attestedCredential, uint16(len(a.credentialId)))
+	attestedCredential = append(attestedCredential, a.credentialId...)
+	attestedCredential = append(attestedCredential, publicKey...)
+	attestationObject, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authenticatorData(protocol.FlagAttestedCredentialData, attestedCredential),
+	})
+	if err != nil {
+		t.Fatalf("failed to encode attestation: %s", err)
+	}
+	return a.request(t, map[string]any{
+		"clientDataJSON":    encodeBase64Url(a.clientData(t, "webauthn.create", options.Response.Challenge)),
+		"attestationObject": encodeBase64Url(attestationObject),
+	})
+}
+
+// get answers the options of a login with an assertion of the passkey
+func (a *softwareAuthenticator) get(t *testing.T, options *protocol.CredentialAssertion) *http.Request {
+	t.Helper()
+	a.signCount++
+	authenticatorData := a.authenticatorData(0, nil)
+	clientData := a.clientData(t, "webauthn.get", options.Response.Challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authenticatorData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign assertion: %s", err)
+	}
+	return a.request(t, map[string]any{
+		"clientDataJSON":    encodeBase64Url(clientData),
+		"authenticatorData": encodeBase64Url(authenticatorData),
+		"signature":         encodeBase64Url(signature),
+		"userHandle":        encodeBase64Url(a.userHandle),
+	})
+}
+
+func (a *softwareAuthenticator) request(t *testing.T, response map[string]any) *http.Request {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{
+		"id":       encodeBase64Url(a.credentialId),
+		"rawId":    encodeBase64Url(a.credentialId),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatalf("failed to encode credential: %s", err)
+	}
+	request := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	return request
+}
+
+func setupPasskeys(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	err := AddKey(dir, "test", false)
+	if err != nil {
+		t.Fatalf("failed to add signing key: %s", err)
+	}
+	err = LoadKeyring(dir, "test")
+	if err != nil {
+		t.Fatalf("failed to load signing key: %s", err)
+	}
+	err = InitPasskeys(testRpId, []string{testOrigin})
+	if err != nil {
+		t.Fatalf("failed to configure passkeys: %s", err)
+	}
+}
+
+// registerPasskey registers the passkey of authenticator for user and returns the saved credential
+func registerPasskey(t *testing.T, authenticator *softwareAuthenticator, user PasskeyUser) webauthn.Credential {
+	t.Helper()
+	options, state, err := BeginPasskeyRegistration(user)
+	if err != nil {
+		t.Fatalf("failed to begin registration: %s", err)
+	}
+	credential, err := FinishPasskeyRegistration(user, state, authenticator.create(t, options))
+	if err != nil {
+		t.Fatalf("failed to finish registration: %s", err)
+	}
+	if !bytes.Equal(credential.ID, authenticator.credentialId) {
+		t.Fatalf("registered credential %x, expected %x", credential.ID, authenticator.credentialId)
+	}
+	return *credential
+}
+
+func loginWithPasskey(t *testing.T, authenticator *softwareAuthenticator, user PasskeyUser) (PasskeyUser, *webauthn.Credential, error) {
+	t.Helper()
+	options, state, err := BeginPasskeyLogin()
+	if err != nil {
+		t.Fatalf("failed to begin login: %s", err)
+	}
+	return FinishPasskeyLogin(state, authenticator.get(t, options), func(userHandle []byte) (PasskeyUser, error) {
+		userId, err := PasskeyUserId(userHandle)
+		if err != nil {
+			return PasskeyUser{}, err
+		}
+		if userId != user.Id {
+			return PasskeyUser{}, errors.New("unknown user")
+		}
+		return user, nil
+	})
+}
+
+func TestPasskeyRegistrationAndLogin(t *testing.T) {
+	setupPasskeys(t)
+	authenticator := newSoftwareAuthenticator(t)
+	user := PasskeyUser{Id: 42, Name: "Jane Doe", Email: "jane@example.com"}
+	user.Credentials = append(user.Credentials, registerPasskey(t, authenticator, user))
+
+	loggedIn, credential, err := loginWithPasskey(t, authenticator, user)
+	if err != nil {
+		t.Fatalf("failed to log in: %s", err)
+	}
+	if loggedIn.Id != user.Id {
+		t.Errorf("logged in as user %d, expected %d", loggedIn.Id, user.Id)
+	}
+	if credential.Authenticator.SignCount != authenticator.signCount {
+		t.Errorf("sign count is %d, expected %d", credential.Authenticator.SignCount, authenticator.signCount)
+	}
+}
+
+func TestPasskeyLoginRejectsOtherKey(t *testing.T) {
+	setupPasskeys(t)
+	authenticator := newSoftwareAuthenticator(t)
+	user := PasskeyUser{Id: 42, Name: "Jane Doe", Email: "jane@example.com"}
+	user.Credentials = append(user.Credentials, registerPasskey(t, authenticator, user))
+
+	// An authenticator that claims the same passkey signs with another key
+	forged := newSoftwareAuthenticator(t)
+	forged.credentialId = authenticator.credentialId
+	forged.userHandle = authenticator.userHandle
+	_, _, err := loginWithPasskey(t, forged, user)
+	if err == nil {
+		t.Fatal("logged in with a signature of another key")
+	}
+}
+
+func TestPasskeyLoginRejectsClonedPasskey(t *testing.T) {
+	setupPasskeys(t)
+	authenticator := newSoftwareAuthenticator(t)
+	user := PasskeyUser{Id: 42, Name: "Jane Doe", Email: "jane@example.com"}
+	user.Credentials = append(user.Credentials, registerPasskey(t, authenticator, user))
+	_, credential, err := loginWithPasskey(t, authenticator, user)
+	if err != nil {
+		t.Fatalf("failed to log in: %s", err)
+	}
+	user.Credentials = []webauthn.Credential{*credential}
+
+	// A copy of the passkey still has the old sign count
+	authenticator.signCount--
+	_, _, err = loginWithPasskey(t, authenticator, user)
+	if !errors.Is(err, ErrPasskeyCloned) {
+		t.Fatalf("expected ErrPasskeyCloned, got %v", err)
+	}
+}
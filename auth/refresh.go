@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// GenerateRefreshToken returns a new random refresh token and the hash under which it is stored
+func GenerateRefreshToken() (token, hash string, err error) {
+	buffer := make([]byte, 32)
+	_, err = rand.Read(buffer)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(buffer)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken returns the hash of a refresh token,
+// only hashes are stored so a leaked database does not contain usable tokens.
+func HashRefreshToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+var totpEncryptionKey []byte
+
+// LoadTotpEncryptionKey sets the key that TOTP secrets are encrypted with,
+// encodedKey is a base64 encoded key of 32 bytes.
+func LoadTotpEncryptionKey(encodedKey string) error {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return fmt.Errorf("cannot decode totp encryption key: %w", err)
+	}
+	if len(key) != 32 {
+		return fmt.Errorf("totp encryption key should be 32 bytes, got %d", len(key))
+	}
+	totpEncryptionKey = key
+	return nil
+}
+
+func totpCipher() (cipher.AEAD, error) {
+	if totpEncryptionKey == nil {
+		return nil, errors.New("totp encryption key is not loaded")
+	}
+	block, err := aes.NewCipher(totpEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+const totpIssuer = "go-form"
+
+// GenerateTotp generates a new TOTP key for the account with accountName
+func GenerateTotp(accountName string) (*otp.Key, error) {
+	return totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: accountName,
+	})
+}
+
+// TotpKey returns the TOTP key of the account with accountName for an existing secret
+func TotpKey(accountName, secret string) (*otp.Key, error) {
+	rawSecret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode totp secret: %w", err)
+	}
+	return totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: accountName,
+		Secret:      rawSecret,
+	})
+}
+
+// TotpQrCode returns a data uri of a png image with the qr code of key,
+// which can be scanned by an authenticator app.
+func TotpQrCode(key *otp.Key) (string, error) {
+	img, err := key.Image(200, 200)
+	if err != nil {
+		return "", fmt.Errorf("failed to create qr code: %w", err)
+	}
+	var buffer bytes.Buffer
+	err = png.Encode(&buffer, img)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode qr code: %w", err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buffer.Bytes()), nil
+}
+
+const (
+	// totpPeriod is how long a TOTP code is valid for
+	totpPeriod = 30
+	// totpSkew is how many periods before and after the current one codes are accepted for,
+	// to allow for clocks that are not in sync
+	totpSkew = 1
+)
+
+// ValidateTotp returns the time step of code when it is a current code of the TOTP secret.
+// Codes of a step at or before lastStep are rejected, so a code that was used cannot be used again.
+func ValidateTotp(code, secret string, lastStep int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	currentStep := time.Now().Unix() / totpPeriod
+	for step := max(currentStep-totpSkew, lastStep+1); step <= currentStep+totpSkew; step++ {
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// EncryptTotpSecret encrypts secret so it can be stored
+func EncryptTotpSecret(secret string) (string, error) {
+	aead, err := totpCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptTotpSecret decrypts a secret that was encrypted with EncryptTotpSecret
+func DecryptTotpSecret(encryptedSecret string) (string, error) {
+	aead, err := totpCipher()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encryptedSecret)
+	if err != nil {
+		return "", fmt.Errorf("cannot decode totp secret: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("totp secret is too short")
+	}
+	secret, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("cannot decrypt totp secret: %w", err)
+	}
+	return string(secret), nil
+}
+
+// GenerateRecoveryCodes returns count codes that can be used once instead of a TOTP code,
+// and the hashes under which they are stored.
+func GenerateRecoveryCodes(count int) (codes, hashes []string, err error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < count; i++ {
+		buffer := make([]byte, 6)
+		_, err = rand.Read(buffer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		code := strings.ToLower(encoding.EncodeToString(buffer))
+		code = code[:5] + "-" + code[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode returns the hash of a recovery code, ignoring case and dashes
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	hash := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+func TestValidateTotpRejectsReplayedCode(t *testing.T) {
+	key, err := GenerateTotp("jane@example.com")
+	if err != nil {
+		t.Fatalf("failed to generate totp key: %s", err)
+	}
+	code, err := totp.GenerateCode(key.Secret(), time.Now())
+	if err != nil {
+		t.Fatalf("failed to generate code: %s", err)
+	}
+	step, ok := ValidateTotp(code, key.Secret(), 0)
+	if !ok {
+		t.Fatal("current code is not valid")
+	}
+	_, ok = ValidateTotp(code, key.Secret(), step)
+	if ok {
+		t.Fatal("code is valid again after it was used")
+	}
+}
+
+func TestValidateTotpAcceptsSkew(t *testing.T) {
+	key, err := GenerateTotp("jane@example.com")
+	if err != nil {
+		t.Fatalf("failed to generate totp key: %s", err)
+	}
+	previous, err := totp.GenerateCode(key.Secret(), time.Now().Add(-totpPeriod*time.Second))
+	if err != nil {
+		t.Fatalf("failed to generate code: %s", err)
+	}
+	_, ok := ValidateTotp(previous, key.Secret(), 0)
+	if !ok {
+		t.Fatal("code of the previous period is not valid")
+	}
+	old, err := totp.GenerateCode(key.Secret(), time.Now().Add(-3*totpPeriod*time.Second))
+	if err != nil {
+		t.Fatalf("failed to generate code: %s", err)
+	}
+	_, ok = ValidateTotp(old, key.Secret(), 0)
+	if ok && old != previous {
+		t.Fatal("code of three periods ago is valid")
+	}
+}
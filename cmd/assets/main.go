@@ -0,0 +1,102 @@
+// assets downloads the scripts and styles that the layout serves itself from /js/vendor and /css/vendor.
+// The docker builds run it before the server is built, run it from the root of the repository otherwise:
+//
+//	go run ./cmd/assets
+//
+// With -missing only the files that do not exist yet are downloaded.
+// It fails when a file cannot be downloaded, does not match its integrity or is missing afterwards.
+package main
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type asset struct {
+	url  string
+	path string
+	// integrity is the published subresource integrity hash, it is checked when it is known
+	integrity string
+}
+
+var assets = []asset{
+	{
+		url:  "https://unpkg.com/htmx.org@2.0.2/dist/htmx.min.js",
+		path: "public/js/vendor/htmx.min.js",
+	},
+	{
+		url:  "https://unpkg.com/htmx-ext-sse@2.2.2/sse.js",
+		path: "public/js/vendor/htmx-ext-sse.js",
+	},
+	{
+		url:       "https://unpkg.com/alpinejs@3.13.5/dist/cdn.min.js",
+		path:      "public/js/vendor/alpine.min.js",
+		integrity: "sha384-BxpSbjbDhVKwnC1UfcjsNEuMuxg4af5IXOaSi1Iq5rASQ/9a7uslhEXbP9UI/fXo",
+	},
+	{
+		url:  "https://cdn.jsdelivr.net/npm/daisyui@4.6.0/dist/full.min.css",
+		path: "public/css/vendor/daisyui.min.css",
+	},
+}
+
+var missing = flag.Bool("missing", false, "Only download the files that do not exist yet.")
+
+func efatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format, args...)
+	os.Exit(1)
+}
+
+func download(client *http.Client, a asset) ([]byte, error) {
+	response, err := client.Get(a.url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != 200 {
+		return nil, fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+	return io.ReadAll(response.Body)
+}
+
+func main() {
+	flag.Parse()
+	client := &http.Client{Timeout: time.Minute}
+	for _, a := range assets {
+		if _, err := os.Stat(a.path); *missing && err == nil {
+			continue
+		}
+		content, err := download(client, a)
+		if err != nil {
+			efatalf("Failed to download %s:\n%s\n", a.url, err)
+		}
+		hash := sha512.Sum384(content)
+		integrity := "sha384-" + base64.StdEncoding.EncodeToString(hash[:])
+		if a.integrity != "" && integrity != a.integrity {
+			efatalf("Integrity of %s is %s, expected %s\n", a.url, integrity, a.integrity)
+		}
+		err = os.MkdirAll(filepath.Dir(a.path), 0755)
+		if err != nil {
+			efatalf("Failed to create directory of %s:\n%s\n", a.path, err)
+		}
+		err = os.WriteFile(a.path, content, 0644)
+		if err != nil {
+			efatalf("Failed to write %s:\n%s\n", a.path, err)
+		}
+		fmt.Printf("%s %s\n", a.path, integrity)
+	}
+	// The layout cannot work without any of the files, so a missing one fails the build
+	for _, a := range assets {
+		if _, err := os.Stat(a.path); errors.Is(err, fs.ErrNotExist) {
+			efatalf("Missing %s\n", a.path)
+		}
+	}
+}
@@ -1,15 +1,20 @@
 package main
 
 import (
-	"crypto/ed25519"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
-	"io/fs"
 	"net/url"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/Kavantix/go-form/auth"
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/pkg/baseurl"
+	"github.com/Kavantix/go-form/pkg/env"
+	"github.com/google/uuid"
 	"github.com/rsc/getopt"
 )
 
@@ -32,20 +37,28 @@ func efatalf(format string, args ...any) {
 }
 
 var (
-	name  = flag.String("name", "key", "The name of the key.")
-	dir   = flag.String("directory", "", "The directory to store the key.")
+	name  = flag.String("name", "key", "The id of the key.")
+	dir   = flag.String("directory", "", "The directory of the keyring.")
 	help  = flag.Bool("help", false, "Shows this help message.")
-	force = flag.Bool("force", false, "Force will allow overwriting existing key files")
+	force = flag.Bool("force", false, "Force will allow overwriting existing key files, or removing the public key when retiring")
+	user  = flag.Int("user", 2, "The id of the user to generate a login link for.")
 )
 
 func failWithUsage() {
 	eprintf("%s is a cli utility to handle signing keys\n", os.Args[0])
 	eprintln("")
 	eprintln("Sub commands:")
-	eprintln("    generate")
-	eprintln("        Generates a new key")
+	eprintln("    add")
+	eprintln("        Adds a new key with --name to the keyring")
+	eprintln("    retire")
+	eprintln("        Removes the private key of --name so it can no longer sign tokens,")
+	eprintln("        with --force the public key is removed as well which invalidates its tokens")
+	eprintln("    list")
+	eprintln("        Lists the keys in the keyring")
 	eprintln("    loginlink")
-	eprintln("        Generates login link")
+	eprintln("        Generates a login link, uses the DB_* env variables to store it")
+	eprintln("")
+	eprintln("The keyring is --directory, or SIGNING_KEYS_DIR when it is not given.")
 	eprintln("Flags:")
 	getopt.PrintDefaults()
 	os.Exit(1)
@@ -56,6 +69,7 @@ func setupArgs() {
 	getopt.Alias("d", "directory")
 	getopt.Alias("h", "help")
 	getopt.Alias("f", "force")
+	getopt.Alias("u", "user")
 	getopt.Parse()
 
 	if *help {
@@ -66,19 +80,63 @@ func setupArgs() {
 func main() {
 	setupArgs()
 
+	err := env.LoadDotEnv()
+	if err != nil {
+		efatalf("Failed to load env: %s\n", err)
+	}
+
 	subcommand := flag.Arg(0)
 	switch subcommand {
-	case "generate":
-		generateKey()
+	case "add", "generate":
+		err := auth.AddKey(keyringDir(), *name, *force)
+		if err != nil {
+			efatalf("Failed to add key: %s\n", err)
+		}
+		eprintf("Added key `%s`, set SIGNING_KEY_ID to sign tokens with it\n", *name)
+	case "retire":
+		if *name == env.Lookup("SIGNING_KEY_ID", "") {
+			efatalf("Key `%s` is the active signing key, change SIGNING_KEY_ID first\n", *name)
+		}
+		err := auth.RetireKey(keyringDir(), *name, *force)
+		if errors.Is(err, auth.ErrOnlyActiveKey) {
+			efatalf("Key `%s` is the only key that can sign tokens, add a new key first\n", *name)
+		} else if err != nil {
+			efatalf("Failed to retire key: %s\n", err)
+		}
+		eprintf("Retired key `%s`\n", *name)
+	case "list":
+		keys, err := auth.ReadKeyring(keyringDir())
+		if err != nil {
+			efatalf("Failed to read keyring: %s\n", err)
+		}
+		activeKeyId := env.Lookup("SIGNING_KEY_ID", "")
+		for _, key := range keys {
+			status := "verify only (retired)"
+			if key.Id == activeKeyId {
+				status = "active"
+			} else if !key.Retired {
+				status = "can sign"
+			}
+			fmt.Printf("%s\t%s\n", key.Id, status)
+		}
 	case "loginlink":
-		privPath, pubPath := paths()
-		err := auth.LoadKeys(privPath, pubPath)
+		err := baseurl.Init(env.MustLookup("BASE_URL"))
+		if err != nil {
+			efatalf("Failed to configure base url: %s\n", err)
+		}
+		err = auth.LoadKeyring(keyringDir(), env.MustLookup("SIGNING_KEY_ID"))
 		if err != nil {
 			efatalf("Failed to load keys: %s\n", err.Error())
 		}
+		jti, err := storeLoginLink(int32(*user))
+		if err != nil {
+			efatalf("Failed to store login link: %s\n", err)
+		}
 		result, err := auth.CreateJwt(&auth.JwtOptions{
+			Id:       jti,
 			Audience: "loginlink",
-			Subject:  "2",
+			Subject:  strconv.Itoa(*user),
+			ValidFor: loginLinkValidFor,
 		})
 		if err != nil {
 			efatalf("Failed to create jwt: %s\n", err)
@@ -88,7 +146,7 @@ func main() {
 			efatalf("Failed to validate jwt: %s\n", err.Error())
 		}
 		eprintf("Claims:\n%+v\n", c)
-		fmt.Printf("http://go-form.test/loginlink?token=%s", url.QueryEscape(result))
+		fmt.Print(baseurl.Url("/loginlink", url.Values{"token": {result}}))
 
 	default:
 		eprintln("Missing subcommand")
@@ -96,39 +154,34 @@ func main() {
 	}
 }
 
-func paths() (privPath, pubPath string) {
-
-	path := "./"
-	if dir != nil && *dir != "" {
-		err := os.MkdirAll(*dir, 0700)
-		if err != nil {
-			efatalf("Failed to create directory `%s`: %s\n", *dir, err.Error())
-		}
-		path = *dir
-		path += "/"
-	}
-	path += *name
-	privPath = path + ".priv"
-	pubPath = path + ".pub"
-	return
-}
-
-func generateKey() {
-	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+const loginLinkValidFor = time.Minute * 5
+
+// storeLoginLink stores a new login link for the user so it can be consumed once
+func storeLoginLink(userId int32) (jti string, err error) {
+	queries, err := database.Connect(
+		env.MustLookup("DB_HOST"),
+		env.Lookup("DB_PORT", "5432"),
+		env.MustLookup("DB_USERNAME"),
+		env.MustLookup("DB_PASSWORD"),
+		env.MustLookup("DB_DATABASE"),
+		env.MustLookup("DB_SSLMODE"),
+	)
 	if err != nil {
-		eprintf("Failed to generate key: %s\n", err.Error())
-		os.Exit(1)
+		return "", fmt.Errorf("failed to connect to database: %w", err)
 	}
-	privPath, pubPath := paths()
-	if !*force {
-		_, privErr := os.Stat(privPath)
-		_, pubErr := os.Stat(pubPath)
-		if !errors.Is(privErr, fs.ErrNotExist) || !errors.Is(pubErr, fs.ErrNotExist) {
-			eprintln("Key already exists, use --force to generate anyways.")
-			os.Exit(1)
-		}
+	defer database.Close()
+	jti = uuid.New().String()
+	_, err = queries.InsertLoginLink(context.Background(), jti, userId, time.Now().Add(loginLinkValidFor))
+	if err != nil {
+		return "", err
 	}
-	os.WriteFile(privPath, auth.Base64Encode(privateKey), 0700)
-	os.WriteFile(pubPath, auth.Base64Encode(publicKey), 0700)
+	return jti, nil
+}
 
+// keyringDir returns the directory of the keyring
+func keyringDir() string {
+	if *dir != "" {
+		return *dir
+	}
+	return env.Lookup("SIGNING_KEYS_DIR", "keys")
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/Kavantix/go-form/templates"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// csrfProtection requires state-changing requests to include the token from the goform_csrf cookie,
+// in the X-CSRF-Token header for htmx and fetch requests or in the _csrf field of forms.
+// The token is made available to the templates with the csrfToken context value.
+func csrfProtection(isProduction bool) echo.MiddlewareFunc {
+	csrf := middleware.CSRFWithConfig(middleware.CSRFConfig{
+		Skipper: func(c echo.Context) bool {
+			// Api tokens are not sent by browsers automatically
+			// and violation reports are sent by the browser without the token
+			_, hasBearerToken := bearerToken(c)
+			return hasBearerToken || c.Request().URL.Path == "/csp-report"
+		},
+		TokenLookup:    "header:X-CSRF-Token,form:_csrf",
+		ContextKey:     "csrf",
+		CookieName:     "goform_csrf",
+		CookiePath:     "/",
+		CookieSecure:   isProduction,
+		CookieHTTPOnly: true,
+		CookieSameSite: http.SameSiteLaxMode,
+		ErrorHandler: func(err error, c echo.Context) error {
+			logger.EchoInfo(c, "Invalid csrf token", slog.String("reason", err.Error()))
+			if isHtmx(c) {
+				// The failure is shown as a page instead of in the target of the request
+				c.Response().Header().Set("HX-Retarget", "body")
+				c.Response().Header().Set("HX-Reswap", "outerHTML")
+			}
+			return template(c, 403, templates.CsrfFailure())
+		},
+	})
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return csrf(func(c echo.Context) error {
+			token, _ := c.Get("csrf").(string)
+			c.SetRequest(c.Request().WithContext(context.WithValue(c.Request().Context(), "csrfToken", token)))
+			return next(c)
+		})
+	}
+}
@@ -2,6 +2,14 @@ package database
 
 import "context"
 
+type AnswerOption struct {
+	Label   string `json:"label"`
+	Correct bool   `json:"correct"`
+}
+
+// AnswerOptions are stored as jsonb in the answer_options column of assignments
+type AnswerOptions []AnswerOption
+
 func (q *Queries) GetAssignmentsPage(ctx context.Context, page, pageSize int) ([]Assignment, error) {
 	return q.getAssignmentsPage(ctx, int32(pageSize), int32(page*pageSize))
 }
@@ -13,7 +13,7 @@ import (
 
 const getAssignment = `-- name: GetAssignment :one
 select
-  id, name, "order", created_at, updated_at, type
+  id, name, "order", created_at, updated_at, type, answer_options
 from assignments
 where id = $1
 `
@@ -28,6 +28,7 @@ func (q *Queries) GetAssignment(ctx context.Context, id int32) (Assignment, erro
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Type,
+		&i.AnswerOptions,
 	)
 	return i, err
 }
@@ -42,12 +43,13 @@ with max_order as (
 ) insert into assignments(
   name,
   "type",
-  "order"
-) values ($1, $2, (select "order" + 1 from max_order)) returning id
+  "order",
+  answer_options
+) values ($1, $2, (select "order" + 1 from max_order), $3) returning id
 `
 
-func (q *Queries) InsertAssignment(ctx context.Context, name string, type_ string) (int32, error) {
-	row := q.db.QueryRow(ctx, insertAssignment, name, type_)
+func (q *Queries) InsertAssignment(ctx context.Context, name string, type_ string, answerOptions AnswerOptions) (int32, error) {
+	row := q.db.QueryRow(ctx, insertAssignment, name, type_, answerOptions)
 	var id int32
 	err := row.Scan(&id)
 	return id, err
@@ -57,15 +59,17 @@ const updateAssignment = `-- name: UpdateAssignment :exec
 update assignments set
   name = coalesce($2, name),
   "type" = coalesce($3, "type"),
-  "order" = coalesce(cast($4 as int4), "order")
+  "order" = coalesce(cast($4 as int4), "order"),
+  answer_options = $5
 where id = $1
 `
 
 type UpdateAssignmentParams struct {
-	Id    int32       `db:"id"`
-	Name  pgtype.Text `db:"name"`
-	Type  pgtype.Text `db:"type"`
-	Order pgtype.Int4 `db:"order"`
+	Id            int32         `db:"id"`
+	Name          pgtype.Text   `db:"name"`
+	Type          pgtype.Text   `db:"type"`
+	Order         pgtype.Int4   `db:"order"`
+	AnswerOptions AnswerOptions `db:"answer_options"`
 }
 
 func (q *Queries) UpdateAssignment(ctx context.Context, arg UpdateAssignmentParams) error {
@@ -74,13 +78,14 @@ func (q *Queries) UpdateAssignment(ctx context.Context, arg UpdateAssignmentPara
 		arg.Name,
 		arg.Type,
 		arg.Order,
+		arg.AnswerOptions,
 	)
 	return err
 }
 
 const getAssignmentsPage = `-- name: getAssignmentsPage :many
 select
-  id, name, "order", created_at, updated_at, type
+  id, name, "order", created_at, updated_at, type, answer_options
 from assignments
 order by "order"
 limit $1 offset $2
@@ -102,6 +107,7 @@ func (q *Queries) getAssignmentsPage(ctx context.Context, limit int32, offset in
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Type,
+			&i.AnswerOptions,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,7 @@
+package database
+
+import "context"
+
+func (q *Queries) GetCaughtMailsPage(ctx context.Context, page, pageSize int) ([]CaughtMail, error) {
+	return q.getCaughtMailsPage(ctx, int32(pageSize), int32(page*pageSize))
+}
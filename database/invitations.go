@@ -0,0 +1,17 @@
+package database
+
+import "context"
+
+const (
+	UserStatusActive = "active"
+	// UserStatusPending is the status of invited users until they accept their invitation
+	UserStatusPending = "pending"
+)
+
+type InsertInvitedUserParams = insertInvitedUserParams
+
+// InsertInvitedUser creates a user that can only log in after accepting an invitation
+func (q *Queries) InsertInvitedUser(ctx context.Context, arg InsertInvitedUserParams) (int32, error) {
+	id, err := q.insertInvitedUser(ctx, arg)
+	return id, checkDuplicateEmailErr(err)
+}
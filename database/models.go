@@ -6,22 +6,106 @@ package database
 
 import (
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ApiToken struct {
+	Id         int32            `db:"id"`
+	UserID     int32            `db:"user_id"`
+	Name       string           `db:"name"`
+	TokenHash  string           `db:"token_hash"`
+	Scopes     []string         `db:"scopes"`
+	CreatedAt  time.Time        `db:"created_at"`
+	LastUsedAt pgtype.Timestamp `db:"last_used_at"`
+	ExpiresAt  pgtype.Timestamp `db:"expires_at"`
+}
+
 type Assignment struct {
+	Id            int32         `db:"id"`
+	Name          string        `db:"name"`
+	Order         int32         `db:"order"`
+	CreatedAt     time.Time     `db:"created_at"`
+	UpdatedAt     time.Time     `db:"updated_at"`
+	Type          string        `db:"type"`
+	AnswerOptions AnswerOptions `db:"answer_options"`
+}
+
+type AuditLog struct {
+	Id        int32       `db:"id"`
+	ActorID   pgtype.Int4 `db:"actor_id"`
+	UserID    pgtype.Int4 `db:"user_id"`
+	Method    string      `db:"method"`
+	Path      string      `db:"path"`
+	Status    int32       `db:"status"`
+	CreatedAt time.Time   `db:"created_at"`
+}
+
+type CaughtMail struct {
+	Id          int32     `db:"id"`
+	FromAddress string    `db:"from_address"`
+	ToAddresses []string  `db:"to_addresses"`
+	CcAddresses []string  `db:"cc_addresses"`
+	Subject     string    `db:"subject"`
+	PlainText   string    `db:"plain_text"`
+	Html        string    `db:"html"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+type DisplayableUser struct {
+	Id          int32       `db:"id"`
+	Name        string      `db:"name"`
+	Email       string      `db:"email"`
+	DateOfBirth time.Time   `db:"date_of_birth"`
+	Role        string      `db:"role"`
+	Status      string      `db:"status"`
+	Locale      pgtype.Text `db:"locale"`
+}
+
+type FormDraft struct {
 	Id        int32     `db:"id"`
-	Name      string    `db:"name"`
-	Order     int32     `db:"order"`
+	UserID    int32     `db:"user_id"`
+	Resource  string    `db:"resource"`
+	RowID     int32     `db:"row_id"`
+	Fields    []byte    `db:"fields"`
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
-	Type      string    `db:"type"`
 }
 
-type DisplayableUser struct {
-	Id          int32     `db:"id"`
-	Name        string    `db:"name"`
-	Email       string    `db:"email"`
-	DateOfBirth time.Time `db:"date_of_birth"`
+type Invitation struct {
+	Id         int32            `db:"id"`
+	Jti        string           `db:"jti"`
+	UserID     int32            `db:"user_id"`
+	InvitedBy  pgtype.Int4      `db:"invited_by"`
+	CreatedAt  time.Time        `db:"created_at"`
+	ExpiresAt  time.Time        `db:"expires_at"`
+	AcceptedAt pgtype.Timestamp `db:"accepted_at"`
+}
+
+type LoginLink struct {
+	Id        int32            `db:"id"`
+	Jti       string           `db:"jti"`
+	UserID    int32            `db:"user_id"`
+	CreatedAt time.Time        `db:"created_at"`
+	ExpiresAt time.Time        `db:"expires_at"`
+	UsedAt    pgtype.Timestamp `db:"used_at"`
+}
+
+type Outbox struct {
+	Id            int32            `db:"id"`
+	FromAddress   string           `db:"from_address"`
+	ToAddresses   []string         `db:"to_addresses"`
+	CcAddresses   []string         `db:"cc_addresses"`
+	Subject       string           `db:"subject"`
+	PlainText     string           `db:"plain_text"`
+	Html          string           `db:"html"`
+	Status        string           `db:"status"`
+	Attempts      int32            `db:"attempts"`
+	NextAttemptAt time.Time        `db:"next_attempt_at"`
+	LastError     pgtype.Text      `db:"last_error"`
+	CreatedAt     time.Time        `db:"created_at"`
+	SentAt        pgtype.Timestamp `db:"sent_at"`
+	ExpiresAt     pgtype.Timestamp `db:"expires_at"`
 }
 
 type ReloginToken struct {
@@ -29,13 +113,74 @@ type ReloginToken struct {
 	Token     string    `db:"token"`
 	UserID    int32     `db:"user_id"`
 	CreatedAt time.Time `db:"created_at"`
+	Attempts  int32     `db:"attempts"`
+}
+
+type Passkey struct {
+	Id           int32            `db:"id"`
+	UserID       int32            `db:"user_id"`
+	Name         string           `db:"name"`
+	CredentialID []byte           `db:"credential_id"`
+	Credential   []byte           `db:"credential"`
+	CreatedAt    time.Time        `db:"created_at"`
+	LastUsedAt   pgtype.Timestamp `db:"last_used_at"`
+}
+
+type PasskeyCeremony struct {
+	Id        string    `db:"id"`
+	State     string    `db:"state"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type RateLimit struct {
+	Key     string    `db:"key"`
+	Hits    int32     `db:"hits"`
+	ResetAt time.Time `db:"reset_at"`
+}
+
+type RecoveryCode struct {
+	Id        int32            `db:"id"`
+	UserID    int32            `db:"user_id"`
+	CodeHash  string           `db:"code_hash"`
+	CreatedAt time.Time        `db:"created_at"`
+	UsedAt    pgtype.Timestamp `db:"used_at"`
+}
+
+type RoleSetting struct {
+	Role             string `db:"role"`
+	RequireTwoFactor bool   `db:"require_two_factor"`
+}
+
+type Session struct {
+	Id                       int32       `db:"id"`
+	Jti                      string      `db:"jti"`
+	UserID                   int32       `db:"user_id"`
+	UserAgent                string      `db:"user_agent"`
+	IpAddress                string      `db:"ip_address"`
+	CreatedAt                time.Time   `db:"created_at"`
+	LastSeenAt               time.Time   `db:"last_seen_at"`
+	RefreshTokenHash         string      `db:"refresh_token_hash"`
+	PreviousRefreshTokenHash string      `db:"previous_refresh_token_hash"`
+	RefreshedAt              time.Time   `db:"refreshed_at"`
+	ImpersonatorID           pgtype.Int4 `db:"impersonator_id"`
 }
 
 type User struct {
-	Id          int32     `db:"id"`
-	Email       string    `db:"email"`
-	CreatedAt   time.Time `db:"created_at"`
-	DateOfBirth time.Time `db:"date_of_birth"`
-	Name        string    `db:"name"`
-	UpdatedAt   time.Time `db:"updated_at"`
+	Id          int32       `db:"id"`
+	Email       string      `db:"email"`
+	CreatedAt   time.Time   `db:"created_at"`
+	DateOfBirth time.Time   `db:"date_of_birth"`
+	Name        string      `db:"name"`
+	UpdatedAt   time.Time   `db:"updated_at"`
+	Role        string      `db:"role"`
+	Status      string      `db:"status"`
+	Locale      pgtype.Text `db:"locale"`
+}
+
+type UserTotp struct {
+	UserID          int32            `db:"user_id"`
+	SecretEncrypted string           `db:"secret_encrypted"`
+	CreatedAt       time.Time        `db:"created_at"`
+	ConfirmedAt     pgtype.Timestamp `db:"confirmed_at"`
+	LastUsedStep    int64            `db:"last_used_step"`
 }
@@ -0,0 +1,14 @@
+package database
+
+import "context"
+
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	// OutboxStatusFailed is the status of messages that are no longer retried
+	OutboxStatusFailed = "failed"
+)
+
+func (q *Queries) GetOutboxPage(ctx context.Context, page, pageSize int) ([]Outbox, error) {
+	return q.getOutboxPage(ctx, int32(pageSize), int32(page*pageSize))
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimitCleanupInterval is how often windows that ended are deleted from the rate_limits table
+const rateLimitCleanupInterval = time.Minute * 10
+
+// RateLimitStore keeps rate limit hits in postgres, so they are shared between instances of the server
+type RateLimitStore struct {
+	queries *Queries
+
+	mutex       sync.Mutex
+	lastCleanup time.Time
+}
+
+func NewRateLimitStore(queries *Queries) *RateLimitStore {
+	return &RateLimitStore{
+		queries:     queries,
+		lastCleanup: time.Now(),
+	}
+}
+
+func (s *RateLimitStore) Hit(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	now := time.Now()
+	err := s.cleanup(ctx, now)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	row, err := s.queries.HitRateLimit(ctx, key, now.Add(window), now)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return int(row.Hits), row.ResetAt, nil
+}
+
+func (s *RateLimitStore) cleanup(ctx context.Context, now time.Time) error {
+	s.mutex.Lock()
+	if now.Sub(s.lastCleanup) < rateLimitCleanupInterval {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.lastCleanup = now
+	s.mutex.Unlock()
+	return s.queries.DeleteEndedRateLimits(ctx, now)
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/Kavantix/go-form/pkg/ratelimit/ratelimittest"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestRateLimitStore needs a migrated database at TEST_DATABASE_URL, its hits are rolled back afterwards
+func TestRateLimitStore(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer pool.Close()
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %s", err)
+	}
+	defer tx.Rollback(ctx)
+
+	ratelimittest.TestStore(t, NewRateLimitStore(New(tx)))
+}
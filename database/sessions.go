@@ -0,0 +1,17 @@
+package database
+
+import "context"
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Roles are all roles a user can have
+var Roles = []string{RoleUser, RoleAdmin}
+
+type SessionWithEmail = getSessionsPageRow
+
+func (q *Queries) GetSessionsPage(ctx context.Context, page, pageSize int) ([]SessionWithEmail, error) {
+	return q.getSessionsPage(ctx, int32(pageSize), int32(page*pageSize))
+}
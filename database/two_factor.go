@@ -0,0 +1,18 @@
+package database
+
+import "context"
+
+// ReplaceRecoveryCodes removes the recovery codes of a user and stores the given hashes instead
+func (q *Queries) ReplaceRecoveryCodes(ctx context.Context, userId int32, codeHashes []string) error {
+	err := q.DeleteRecoveryCodes(ctx, userId)
+	if err != nil {
+		return err
+	}
+	for _, codeHash := range codeHashes {
+		err = q.InsertRecoveryCode(ctx, userId, codeHash)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -14,8 +14,17 @@ var (
 	ErrDuplicateEmail = errors.New("email already exists")
 )
 
+// MaxReloginAttempts is the number of times a wrong relogin token can be entered
+// before the token that was sent is no longer accepted
+const MaxReloginAttempts = 5
+
 func (q *Queries) ConsumeReloginToken(ctx context.Context, userId int32, token string, createdAfter time.Time) error {
-	deletedIds, err := q.consumeReloginToken(ctx, token, userId, createdAfter)
+	deletedIds, err := q.consumeReloginToken(ctx, consumeReloginTokenParams{
+		Token:        token,
+		UserID:       userId,
+		CreatedAfter: createdAfter,
+		MaxAttempts:  MaxReloginAttempts,
+	})
 	if err != nil {
 		return err
 	}
@@ -8,11 +8,60 @@ package database
 import (
 	"context"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const deleteStaleReloginTokens = `-- name: DeleteStaleReloginTokens :exec
+delete from relogin_tokens
+where user_id = $1
+  or created_at < $2
+`
+
+func (q *Queries) DeleteStaleReloginTokens(ctx context.Context, userID int32, createdBefore time.Time) error {
+	_, err := q.db.Exec(ctx, deleteStaleReloginTokens, userID, createdBefore)
+	return err
+}
+
+const getActiveUsers = `-- name: GetActiveUsers :many
+select
+  id, name, email, date_of_birth, role, status, locale
+from displayable_users
+where status = 'active'
+order by id
+`
+
+func (q *Queries) GetActiveUsers(ctx context.Context) ([]DisplayableUser, error) {
+	rows, err := q.db.Query(ctx, getActiveUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DisplayableUser{}
+	for rows.Next() {
+		var i DisplayableUser
+		if err := rows.Scan(
+			&i.Id,
+			&i.Name,
+			&i.Email,
+			&i.DateOfBirth,
+			&i.Role,
+			&i.Status,
+			&i.Locale,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUser = `-- name: GetUser :one
 select 
-  id, name, email, date_of_birth
+  id, name, email, date_of_birth, role, status, locale
 from displayable_users
 where id = $1
 limit 1
@@ -26,13 +75,16 @@ func (q *Queries) GetUser(ctx context.Context, id int32) (DisplayableUser, error
 		&i.Name,
 		&i.Email,
 		&i.DateOfBirth,
+		&i.Role,
+		&i.Status,
+		&i.Locale,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
 select 
-  id, name, email, date_of_birth
+  id, name, email, date_of_birth, role, status, locale
 from displayable_users
 where email = $1
 limit 1
@@ -46,10 +98,40 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (Displayable
 		&i.Name,
 		&i.Email,
 		&i.DateOfBirth,
+		&i.Role,
+		&i.Status,
+		&i.Locale,
 	)
 	return i, err
 }
 
+const hasValidReloginToken = `-- name: HasValidReloginToken :one
+select exists(
+  select
+  from relogin_tokens
+  where user_id = $1
+    and created_at > $2
+)
+`
+
+func (q *Queries) HasValidReloginToken(ctx context.Context, userID int32, createdAfter time.Time) (bool, error) {
+	row := q.db.QueryRow(ctx, hasValidReloginToken, userID, createdAfter)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const incrementReloginTokenAttempts = `-- name: IncrementReloginTokenAttempts :exec
+update relogin_tokens set
+  attempts = attempts + 1
+where user_id = $1
+`
+
+func (q *Queries) IncrementReloginTokenAttempts(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, incrementReloginTokenAttempts, userID)
+	return err
+}
+
 const insertReloginToken = `-- name: InsertReloginToken :one
 insert into relogin_tokens (
   user_id,
@@ -64,6 +146,17 @@ func (q *Queries) InsertReloginToken(ctx context.Context, userID int32, token st
 	return id, err
 }
 
+const updateUserLocale = `-- name: UpdateUserLocale :exec
+update users set
+  locale = $2
+where id = $1
+`
+
+func (q *Queries) UpdateUserLocale(ctx context.Context, id int32, locale pgtype.Text) error {
+	_, err := q.db.Exec(ctx, updateUserLocale, id, locale)
+	return err
+}
+
 const userWithEmailExists = `-- name: UserWithEmailExists :one
 select exists(
   select
@@ -85,10 +178,23 @@ delete from relogin_tokens
 where token = $1
   and user_id = $2
   and created_at > $3
+  and attempts < $4
 `
 
-func (q *Queries) consumeReloginToken(ctx context.Context, token string, userID int32, createdAfter time.Time) (int64, error) {
-	result, err := q.db.Exec(ctx, consumeReloginToken, token, userID, createdAfter)
+type consumeReloginTokenParams struct {
+	Token        string    `db:"token"`
+	UserID       int32     `db:"user_id"`
+	CreatedAfter time.Time `db:"created_after"`
+	MaxAttempts  int32     `db:"max_attempts"`
+}
+
+func (q *Queries) consumeReloginToken(ctx context.Context, arg consumeReloginTokenParams) (int64, error) {
+	result, err := q.db.Exec(ctx, consumeReloginToken,
+		arg.Token,
+		arg.UserID,
+		arg.CreatedAfter,
+		arg.MaxAttempts,
+	)
 	if err != nil {
 		return 0, err
 	}
@@ -97,7 +203,7 @@ func (q *Queries) consumeReloginToken(ctx context.Context, token string, userID
 
 const getUsersPage = `-- name: getUsersPage :many
 select 
-  id, name, email, date_of_birth
+  id, name, email, date_of_birth, role, status, locale
 from displayable_users
 order by id
 limit $1 offset $2
@@ -117,6 +223,9 @@ func (q *Queries) getUsersPage(ctx context.Context, limit int32, offset int32) (
 			&i.Name,
 			&i.Email,
 			&i.DateOfBirth,
+			&i.Role,
+			&i.Status,
+			&i.Locale,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/mails"
+	"github.com/Kavantix/go-form/pkg/i18n"
+	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/Kavantix/go-form/templates"
+	"github.com/Kavantix/go-form/templates/components"
+	"github.com/a-h/templ"
+	"github.com/labstack/echo/v4"
+)
+
+func renderDevMails(c echo.Context, queries *database.Queries, catcherEnabled bool, toasts ...components.ToastConfig) error {
+	var caughtMails []database.CaughtMail
+	if catcherEnabled {
+		page, pageSize, err := paginationParams(c)
+		if err != nil {
+			return c.String(400, "invalid pagination")
+		}
+		caughtMails, err = queries.GetCaughtMailsPage(c.Request().Context(), page, pageSize)
+		if err != nil {
+			return fmt.Errorf("failed to get caught mails: %w", err)
+		}
+	}
+	templatesToRender := []templ.Component{
+		templates.DevMails(caughtMails, mails.PreviewNames(), catcherEnabled),
+	}
+	for _, toast := range toasts {
+		templatesToRender = append(templatesToRender, components.Toast(toast))
+	}
+	return template(c, 200, templatesToRender...)
+}
+
+func HandleDevMails(queries *database.Queries, catcherEnabled bool) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return renderDevMails(c, queries, catcherEnabled)
+	}
+}
+
+func HandleClearDevMails(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		deleted, err := queries.DeleteCaughtMails(c.Request().Context())
+		if err != nil {
+			return fmt.Errorf("failed to clear caught mails: %w", err)
+		}
+		logger.EchoInfo(c, "Cleared caught mails", slog.Int64("mails", deleted))
+		if !isHtmx(c) {
+			return c.Redirect(303, "/dev/mails")
+		}
+		return renderDevMails(c, queries, true, components.ToastConfig{
+			Message: i18n.T(c.Request().Context(), "Deleted %d mails", deleted),
+			Variant: components.ToastSuccess,
+		})
+	}
+}
+
+// getCaughtMail returns the caught mail of the id param, when it does not exist the not found page is rendered and ok is false
+func getCaughtMail(c echo.Context, queries *database.Queries) (mail database.CaughtMail, ok bool, err error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return mail, false, c.String(400, "invalid id")
+	}
+	mail, err = queries.GetCaughtMail(c.Request().Context(), int32(id))
+	if errors.Is(err, database.ErrNotFound) {
+		return mail, false, template(c, 404, templates.NotFound("/dev/mails"))
+	} else if err != nil {
+		return mail, false, fmt.Errorf("failed to get caught mail: %w", err)
+	}
+	return mail, true, nil
+}
+
+func HandleDevMail(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		mail, ok, err := getCaughtMail(c, queries)
+		if !ok {
+			return err
+		}
+		return template(c, 200, templates.DevMail(templates.MailView{
+			From:      mail.FromAddress,
+			To:        mail.ToAddresses,
+			Subject:   mail.Subject,
+			PlainText: mail.PlainText,
+			HtmlUrl:   fmt.Sprintf("/dev/mails/%d/html", mail.Id),
+		}))
+	}
+}
+
+func HandleDevMailHtml(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		mail, ok, err := getCaughtMail(c, queries)
+		if !ok {
+			return err
+		}
+		return mailHtml(c, mail.Html)
+	}
+}
+
+func HandleMailPreview() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		name := c.Param("name")
+		email, ok := mails.Preview(name, i18n.FromContext(c.Request().Context()))
+		if !ok {
+			return template(c, 404, templates.NotFound("/dev/mails"))
+		}
+		return template(c, 200, templates.DevMail(templates.MailView{
+			From:      "go-form",
+			To:        []string{"jane@example.com"},
+			Subject:   email.Subject(),
+			PlainText: email.PlainText(),
+			HtmlUrl:   fmt.Sprintf("/dev/mails/previews/%s/html", name),
+		}))
+	}
+}
+
+func HandleMailPreviewHtml() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		email, ok := mails.Preview(c.Param("name"), i18n.FromContext(c.Request().Context()))
+		if !ok {
+			return template(c, 404, templates.NotFound("/dev/mails"))
+		}
+		return mailHtml(c, email.Html())
+	}
+}
+
+// mailHtml serves the html of a mail to show in the frame of the mail page.
+// Mails have inline styles, so they get a policy of their own that still blocks scripts,
+// and links open in the window instead of the frame.
+func mailHtml(c echo.Context, html string) error {
+	c.Response().Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; img-src * data:; font-src *; frame-ancestors 'self'")
+	c.Response().Header().Set("X-Frame-Options", "SAMEORIGIN")
+	html = strings.Replace(html, "<head>", `<head><base target="_top">`, 1)
+	return c.HTML(200, html)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/Kavantix/go-form/disks"
 	"github.com/Kavantix/go-form/interfaces"
+	"github.com/Kavantix/go-form/pkg/baseurl"
 )
 
 func ResolveDisk(
@@ -17,7 +18,7 @@ func ResolveDisk(
 	uploadDisk := LookupEnv("UPLOAD_DISK", "local")
 	switch uploadDisk {
 	case "local":
-		disk = disks.NewLocal("./storage/public", "/storage", disks.LocalDiskModePublic)
+		disk = disks.NewLocal("./storage/public", baseurl.Url("/storage", nil), disks.LocalDiskModePublic)
 	case "do-spaces":
 		disk, err = disks.NewDOSpaces(
 			MustLookupEnv("DO_SPACES_REGION"),
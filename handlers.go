@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -15,6 +17,8 @@ import (
 	"github.com/Kavantix/go-form/database"
 	"github.com/Kavantix/go-form/interfaces"
 	"github.com/Kavantix/go-form/mails"
+	"github.com/Kavantix/go-form/pkg/baseurl"
+	"github.com/Kavantix/go-form/pkg/i18n"
 	"github.com/Kavantix/go-form/pkg/logger"
 	"github.com/Kavantix/go-form/resources"
 	"github.com/Kavantix/go-form/templates"
@@ -22,22 +26,70 @@ import (
 	"github.com/a-h/templ"
 	"github.com/getsentry/sentry-go"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/labstack/echo/v4"
 )
 
-func tryGetUserIdFromCookie(e echo.Context, allowExpired bool) (int32, error) {
+// tryGetSessionFromCookie returns the session that the auth cookie belongs to,
+// sessions that were revoked result in an error.
+func tryGetSessionFromCookie(e echo.Context, queries *database.Queries, allowExpired bool) (database.GetSessionByJtiRow, error) {
 	token, err := e.Cookie("goform_auth")
 	if err != nil {
-		return 0, err
+		return database.GetSessionByJtiRow{}, err
 	}
 	claims, err := auth.ParseJwt(token.Value)
 	if err != nil && (!allowExpired || !errors.Is(err, auth.ErrTokenExpired)) {
+		return database.GetSessionByJtiRow{}, err
+	}
+	if claims["aud"] != "go-form" {
+		return database.GetSessionByJtiRow{}, fmt.Errorf("invalid audience")
+	}
+	rawUserId, _ := claims["sub"].(string)
+	userId, err := strconv.Atoi(rawUserId)
+	if err != nil {
+		return database.GetSessionByJtiRow{}, err
+	}
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return database.GetSessionByJtiRow{}, fmt.Errorf("missing session id")
+	}
+	session, err := queries.GetSessionByJti(e.Request().Context(), jti)
+	if err != nil {
+		return session, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.UserID != int32(userId) {
+		return session, fmt.Errorf("session belongs to another user")
+	}
+	if auth.ActorSubject(claims) != formatImpersonator(session.ImpersonatorID) {
+		return session, fmt.Errorf("token actor does not match the session")
+	}
+	return session, nil
+}
+
+func tryGetUserIdFromCookie(e echo.Context, queries *database.Queries, allowExpired bool) (int32, error) {
+	session, err := tryGetSessionFromCookie(e, queries, allowExpired)
+	if err != nil {
+		return 0, err
+	}
+	return session.UserID, nil
+}
+
+// tryGetCookieUserId returns the id of the user of the auth cookie without checking its session,
+// expired cookies are accepted so it can be used to rate limit relogins.
+func tryGetCookieUserId(e echo.Context) (int32, error) {
+	token, err := e.Cookie("goform_auth")
+	if err != nil {
+		return 0, err
+	}
+	claims, err := auth.ParseJwt(token.Value)
+	if err != nil && !errors.Is(err, auth.ErrTokenExpired) {
 		return 0, err
 	}
 	if claims["aud"] != "go-form" {
 		return 0, fmt.Errorf("invalid audience")
 	}
-	userId, err := strconv.Atoi(claims["sub"].(string))
+	rawUserId, _ := claims["sub"].(string)
+	userId, err := strconv.Atoi(rawUserId)
 	if err != nil {
 		return 0, err
 	}
@@ -45,7 +97,7 @@ func tryGetUserIdFromCookie(e echo.Context, allowExpired bool) (int32, error) {
 }
 
 func tryGetUserFromCookie(e echo.Context, queries *database.Queries, allowExpired bool) (database.DisplayableUser, error) {
-	userId, err := tryGetUserIdFromCookie(e, allowExpired)
+	userId, err := tryGetUserIdFromCookie(e, queries, allowExpired)
 	if err != nil {
 		return database.DisplayableUser{}, err
 	}
@@ -56,66 +108,102 @@ func tryGetUserFromCookie(e echo.Context, queries *database.Queries, allowExpire
 	return user, nil
 }
 
-// func HandleRelogin(queries *database.Queries) func(c *gin.Context) {
-// 	return func(c *gin.Context) {
-// 		user, err := tryGetUserFromCookie(c, queries, true)
-// 		if err != nil {
-// 			htmxRedirect(c, "/login")
-// 			return
-// 		}
-// 		token, err := auth.GenerateOTP(6)
-// 		if err != nil {
-// 			c.AbortWithError(500, fmt.Errorf("failed to generate relogin token: %w", err))
-// 			return
-// 		}
-// 		_, err = queries.InsertReloginToken(c.Request.Context(), user.Id, token)
-// 		if err != nil {
-// 			c.AbortWithError(500, fmt.Errorf("failed to save relogin token: %w", err))
-// 			return
-// 		}
-// 		mails.Relogin(mails.ReloginMailContent{
-// 			User:  user,
-// 			Token: token,
-// 		}).SendTo(c.Request.Context(), user.Email)
-// 		template(c, 200, templates.ReloginForm(user.Email, "", ""))
-// 	}
-// }
-
-// func HandlePutRelogin(isProduction bool, queries *database.Queries) func(c *gin.Context) {
-// 	return func(c *gin.Context) {
-// 		user, err := tryGetUserFromCookie(c, queries, true)
-// 		if err != nil {
-// 			htmxRedirect(c, "/login")
-// 			return
-// 		}
-// 		tokenInvalid := func(c *gin.Context, token string) {
-// 			c.Header("HX-Reswap", "outerHTML")
-// 			template(c, 422, templates.ReloginForm(user.Email, token, fmt.Sprintf("Token `%s` is invalid", token)))
-// 		}
-// 		token := c.PostForm("token")
-// 		if len(token) != 6 {
-// 			tokenInvalid(c, token)
-// 			return
-// 		}
-// 		createdAfter := time.Now().Add(-time.Minute * 5)
-// 		err = queries.ConsumeReloginToken(c.Request.Context(), user.Id, token, createdAfter)
-// 		if err != nil {
-// 			if errors.Is(err, database.ErrNotFound) {
-// 				tokenInvalid(c, token)
-// 				return
-// 			} else {
-// 				c.AbortWithError(500, err)
-// 				return
-// 			}
-// 		}
-// 		err = setUserLoggedInCookie(c, int(user.Id), isProduction)
-// 		if err != nil {
-// 			c.AbortWithError(500, fmt.Errorf("Failed to create token: %w", err))
-// 			return
-// 		}
-// 		c.Status(200)
-// 	}
-// }
+// reloginTokenValidFor is how long a relogin token that was sent can be used
+const reloginTokenValidFor = time.Minute * 5
+
+func HandleRelogin(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		session, err := tryGetSessionFromCookie(c, queries, true)
+		if err != nil {
+			return htmxRedirect(c, "/login")
+		}
+		if session.ImpersonatorID.Valid {
+			return endExpiredImpersonation(c, queries, session)
+		}
+		user, err := queries.GetUser(c.Request().Context(), session.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		// A new token is only sent once the previous one expired,
+		// otherwise requesting one would reset the attempts that are left to enter it
+		hasValidToken, err := queries.HasValidReloginToken(c.Request().Context(), user.Id, time.Now().Add(-reloginTokenValidFor))
+		if err != nil {
+			return fmt.Errorf("failed to check relogin tokens: %w", err)
+		}
+		if hasValidToken {
+			return template(c, 200, templates.ReloginForm(user.Email, "", ""))
+		}
+		token, err := auth.GenerateOTP(6)
+		if err != nil {
+			return fmt.Errorf("failed to generate relogin token: %w", err)
+		}
+		// Only the latest token of a user is valid, expired tokens of others are cleaned up as well
+		err = queries.DeleteStaleReloginTokens(c.Request().Context(), user.Id, time.Now().Add(-reloginTokenValidFor))
+		if err != nil {
+			return fmt.Errorf("failed to delete stale relogin tokens: %w", err)
+		}
+		_, err = queries.InsertReloginToken(c.Request().Context(), user.Id, token)
+		if err != nil {
+			return fmt.Errorf("failed to save relogin token: %w", err)
+		}
+		err = mails.Relogin(mails.ReloginMailContent{
+			User:      user,
+			Token:     token,
+			ExpiresAt: time.Now().Add(reloginTokenValidFor),
+			Locale:    mailLocale(c, user),
+		}).SendTo(c.Request().Context(), user.Email)
+		if err != nil {
+			return fmt.Errorf("failed to send relogin token: %w", err)
+		}
+		return template(c, 200, templates.ReloginForm(user.Email, "", ""))
+	}
+}
+
+func HandlePutRelogin(isProduction bool, queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		session, err := tryGetSessionFromCookie(c, queries, true)
+		if err != nil {
+			return htmxRedirect(c, "/login")
+		}
+		if session.ImpersonatorID.Valid {
+			return endExpiredImpersonation(c, queries, session)
+		}
+		user, err := queries.GetUser(c.Request().Context(), session.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		tokenInvalid := func(token string) error {
+			c.Response().Header().Set("HX-Reswap", "outerHTML")
+			return template(c, 422, templates.ReloginForm(user.Email, token, i18n.T(c.Request().Context(), "Token `%s` is invalid or expired", token)))
+		}
+		token := c.FormValue("token")
+		if len(token) != 6 {
+			return tokenInvalid(token)
+		}
+		createdAfter := time.Now().Add(-reloginTokenValidFor)
+		err = queries.ConsumeReloginToken(c.Request().Context(), user.Id, token, createdAfter)
+		if errors.Is(err, database.ErrNotFound) {
+			logger.EchoInfo(c, "Invalid relogin token", slog.Int("user", int(user.Id)))
+			err = queries.IncrementReloginTokenAttempts(c.Request().Context(), user.Id)
+			if err != nil {
+				return fmt.Errorf("failed to count relogin attempt: %w", err)
+			}
+			return tokenInvalid(token)
+		} else if err != nil {
+			return fmt.Errorf("failed to consume relogin token: %w", err)
+		}
+		// The expired session is replaced by a new one
+		err = queries.DeleteSessionByJti(c.Request().Context(), session.Jti)
+		if err != nil {
+			return fmt.Errorf("failed to delete expired session: %w", err)
+		}
+		err = setUserLoggedInCookie(c, queries, user.Id, isProduction)
+		if err != nil {
+			return fmt.Errorf("failed to create token: %w", err)
+		}
+		return c.NoContent(200)
+	}
+}
 
 func HandleLogin(queries *database.Queries) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -128,6 +216,9 @@ func HandleLogin(queries *database.Queries) echo.HandlerFunc {
 	}
 }
 
+// loginLinkValidFor is how long a login link can be used
+const loginLinkValidFor = time.Minute * 5
+
 func HandlePostLogin(queries *database.Queries) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		email := c.FormValue("email")
@@ -137,131 +228,337 @@ func HandlePostLogin(queries *database.Queries) echo.HandlerFunc {
 		user, err := queries.GetUserByEmail(c.Request().Context(), email)
 		if errors.Is(err, database.ErrNotFound) {
 			logger.EchoInfo(c, "email not found", slog.String("email", email))
-			return template(c, 200, templates.LoginMessage())
+			return template(c, 200, templates.LoginMessage(""))
 		} else if err != nil {
 			return fmt.Errorf("Failed to check if user exists: %w", err)
 		}
+		if user.Status == database.UserStatusPending {
+			// Invited users log in for the first time with their invitation
+			logger.EchoInfo(c, "login requested for invited user", slog.Int("user", int(user.Id)))
+			return template(c, 200, templates.LoginMessage(""))
+		}
+		jti := uuid.New().String()
+		_, err = queries.InsertLoginLink(c.Request().Context(), jti, user.Id, time.Now().Add(loginLinkValidFor))
+		if err != nil {
+			return fmt.Errorf("Failed to save login link: %w", err)
+		}
 		token, err := auth.CreateJwt(&auth.JwtOptions{
+			Id:       jti,
 			Audience: "loginlink",
 			Subject:  strconv.Itoa(int(user.Id)),
-			ValidFor: time.Minute * 5,
+			ValidFor: loginLinkValidFor,
 		})
 		if err != nil {
 			return fmt.Errorf("Failed to create token: %w", err)
 		}
 
-		link := fmt.Sprintf("http://%s/loginlink?token=%s",
-			c.Request().Host,
-			url.QueryEscape(token),
-		)
+		link := baseurl.Url("/loginlink", url.Values{"token": {token}})
 
-		mails.Login(mails.LoginMailContent{
-			User: user,
-			Link: link,
+		err = mails.Login(mails.LoginMailContent{
+			User:      user,
+			Link:      link,
+			ExpiresAt: time.Now().Add(loginLinkValidFor),
+			Locale:    mailLocale(c, user),
 		}).SendTo(c.Request().Context(), user.Email)
+		if err != nil {
+			return fmt.Errorf("failed to send login link: %w", err)
+		}
 
-		return template(c, 200, templates.LoginMessage())
+		return template(c, 200, templates.LoginMessage(""))
 	}
 }
 
-func HandleLoginLink(isProduction bool, queries *database.Queries) func(c echo.Context) error {
-	return func(e echo.Context) error {
-		tokenString := e.QueryParam("token")
-		if tokenString == "" {
-			logger.EchoWarn(e, "No token provided for login")
-			e.Set("Unauthenticated", true)
-			return nil
-		}
-		claims, err := auth.ParseJwt(tokenString)
-		if err != nil {
-			// TODO: show error to user
-			logger.EchoError(e, "Invalid token: ", err)
-			e.Set("Unauthenticated", true)
-			return nil
+// parseLoginLink returns the id of the login link in tokenString and the user it was created for,
+// when the link cannot be used the error page is rendered and ok is false.
+func parseLoginLink(c echo.Context, tokenString string) (jti string, userId int32, ok bool, err error) {
+	if tokenString == "" {
+		logger.EchoWarn(c, "No token provided for login")
+		return "", 0, false, template(c, 400, templates.LoginLinkError(i18n.T(c.Request().Context(), "This login link is invalid.")))
+	}
+	claims, err := auth.ParseJwt(tokenString)
+	if errors.Is(err, auth.ErrTokenExpired) {
+		logger.EchoInfo(c, "Expired login link")
+		return "", 0, false, template(c, 410, templates.LoginLinkError(i18n.T(c.Request().Context(), "This login link has expired.")))
+	} else if err != nil {
+		logger.EchoError(c, "Invalid token: ", err)
+		return "", 0, false, template(c, 400, templates.LoginLinkError(i18n.T(c.Request().Context(), "This login link is invalid.")))
+	}
+	jti, _ = claims["jti"].(string)
+	rawUserId, _ := claims["sub"].(string)
+	parsedUserId, err := strconv.Atoi(rawUserId)
+	if claims["aud"] != "loginlink" || jti == "" || err != nil {
+		logger.EchoWarn(c, "Invalid token missing claims")
+		return "", 0, false, template(c, 400, templates.LoginLinkError(i18n.T(c.Request().Context(), "This login link is invalid.")))
+	}
+	return jti, int32(parsedUserId), true, nil
+}
+
+// renderUnusableLoginLink renders the error page for a login link that could not be consumed
+func renderUnusableLoginLink(c echo.Context, queries *database.Queries, jti string) error {
+	link, err := queries.GetLoginLink(c.Request().Context(), jti)
+	if errors.Is(err, database.ErrNotFound) {
+		logger.EchoWarn(c, "Unknown login link")
+		return template(c, 400, templates.LoginLinkError(i18n.T(c.Request().Context(), "This login link is invalid.")))
+	} else if err != nil {
+		return fmt.Errorf("failed to get login link: %w", err)
+	}
+	if link.UsedAt.Valid {
+		logger.EchoWarn(c, "Login link reused", slog.Int("user", int(link.UserID)))
+		return template(c, 410, templates.LoginLinkError(i18n.T(c.Request().Context(), "This login link was already used.")))
+	}
+	return template(c, 410, templates.LoginLinkError(i18n.T(c.Request().Context(), "This login link has expired.")))
+}
+
+// HandleLoginLink asks to confirm the login, so links that are opened by mail scanners are not used up
+func HandleLoginLink(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tokenString := c.QueryParam("token")
+		jti, _, ok, err := parseLoginLink(c, tokenString)
+		if !ok {
+			return err
 		}
-		if claims["aud"] != "loginlink" || claims["sub"] == nil {
-			logger.EchoWarn(e, "Invalid token missing claims")
-			e.Set("Unauthenticated", true)
-			return nil
+		link, err := queries.GetLoginLink(c.Request().Context(), jti)
+		if err != nil || link.UsedAt.Valid || time.Now().After(link.ExpiresAt) {
+			return renderUnusableLoginLink(c, queries, jti)
 		}
-		rawUserId, ok := claims["sub"].(string)
+		return template(c, 200, templates.LoginLinkConfirm(tokenString))
+	}
+}
+
+func HandlePostLoginLink(isProduction bool, queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		jti, userId, ok, err := parseLoginLink(c, c.FormValue("token"))
 		if !ok {
-			logger.EchoWarn(e, "No user id in claims")
-			e.Set("Unauthenticated", true)
-			return nil
+			return err
 		}
-		userId, err := strconv.Atoi(rawUserId)
-		if err != nil {
-			logger.EchoError(e, "No valid user id in claims:", err)
-			e.Set("Unauthenticated", true)
-			return nil
+		linkUserId, err := queries.ConsumeLoginLink(c.Request().Context(), jti)
+		if errors.Is(err, database.ErrNotFound) {
+			return renderUnusableLoginLink(c, queries, jti)
+		} else if err != nil {
+			return fmt.Errorf("failed to consume login link: %w", err)
 		}
-		_, err = queries.GetUser(e.Request().Context(), int32(userId))
-		if err != nil {
-			logger.EchoError(e, "No valid user id in claims:", err)
-			e.Set("Unauthenticated", true)
-			return nil
+		if linkUserId != userId {
+			logger.EchoWarn(c, "Login link user does not match token", slog.Int("user", int(userId)))
+			return template(c, 400, templates.LoginLinkError(i18n.T(c.Request().Context(), "This login link is invalid.")))
 		}
 
-		err = setUserLoggedInCookie(e, userId, isProduction)
+		return startLoginSession(c, queries, userId, isProduction)
+	}
+}
+
+// startLoginSession logs in the user after its identity was verified,
+// users with two-factor authentication are asked for their second factor first.
+func startLoginSession(c echo.Context, queries *database.Queries, userId int32, isProduction bool) error {
+	hasTwoFactor, err := userHasTwoFactor(c.Request().Context(), queries, userId)
+	if err != nil {
+		return fmt.Errorf("failed to check two-factor authentication: %w", err)
+	}
+	if hasTwoFactor {
+		// The session is only started after the second factor is verified
+		err = setTwoFactorPendingCookie(c, userId, isProduction)
 		if err != nil {
-			e.Error(fmt.Errorf("Failed to create token: %w", err))
-			return nil
+			return fmt.Errorf("Failed to create token: %w", err)
 		}
-
-		if templates.IsHtmx(e.Request().Context()) {
-			return htmxRedirect(e, "/users")
+		if isHtmx(c) {
+			return htmxRedirect(c, "/login/2fa")
 		} else {
-			return e.Redirect(302, "/users")
+			return c.Redirect(303, "/login/2fa")
 		}
 	}
+
+	err = setUserLoggedInCookie(c, queries, userId, isProduction)
+	if err != nil {
+		return fmt.Errorf("Failed to create token: %w", err)
+	}
+
+	if isHtmx(c) {
+		return htmxRedirect(c, "/users")
+	} else {
+		return c.Redirect(303, "/users")
+	}
 }
 
-func HandleLogout() echo.HandlerFunc {
+func HandleLogout(queries *database.Queries) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		session, err := tryGetSessionFromCookie(c, queries, true)
+		if err == nil {
+			err = queries.DeleteSessionByJti(c.Request().Context(), session.Jti)
+			if err != nil {
+				return fmt.Errorf("failed to delete session: %w", err)
+			}
+		}
 		clearUserLoggedInCookie(c)
 		c.Set("Unauthenticated", true)
 		return nil
 	}
 }
 
+// HandleJwks publishes the public keys that our tokens are signed with
+func HandleJwks() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set("Cache-Control", "public, max-age=300")
+		return c.JSON(200, map[string]any{
+			"keys": auth.Jwks(),
+		})
+	}
+}
+
+const (
+	// accessTokenValidFor is how long the token in the auth cookie is valid,
+	// after that it is reissued using the refresh token.
+	accessTokenValidFor = time.Minute * 15
+	// sessionIdleTimeout is how long a session can be inactive before the refresh token is no longer accepted
+	sessionIdleTimeout = time.Hour * 2
+	// sessionMaxAge is how long a session can be refreshed after logging in
+	sessionMaxAge = time.Hour * 24 * 7
+	// refreshTokenReuseGrace is how long the previous refresh token is still accepted after rotating,
+	// so concurrent requests that were sent with it do not revoke the session.
+	refreshTokenReuseGrace = time.Second * 10
+)
+
+var errRefreshTokenReused = errors.New("refresh token was reused")
+
 func clearUserLoggedInCookie(c echo.Context) {
-	c.SetCookie(&http.Cookie{
+	for _, name := range []string{"goform_auth", "goform_refresh"} {
+		c.SetCookie(&http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/",
+			MaxAge:   -1,
+			Secure:   false,
+			HttpOnly: true,
+		})
+	}
+}
 
-		Name:  "goform_auth",
-		Value: "",
-		// a week in seconds
-		MaxAge:   -1,
-		Secure:   false,
-		HttpOnly: true,
-	},
-	)
+// setUserLoggedInCookie starts a new session for the user and stores its tokens in the auth and refresh cookies
+func setUserLoggedInCookie(c echo.Context, queries *database.Queries, userId int32, isProduction bool) error {
+	return startSession(c, queries, userId, pgtype.Int4{}, isProduction)
 }
 
-func setUserLoggedInCookie(c echo.Context, userId int, isProduction bool) error {
+// startSession starts a new session for the user, impersonatorId is the admin that acts as the user
+func startSession(c echo.Context, queries *database.Queries, userId int32, impersonatorId pgtype.Int4, isProduction bool) error {
+	jti := uuid.New().String()
+	refreshToken, refreshTokenHash, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return err
+	}
+	_, err = queries.InsertSession(c.Request().Context(), database.InsertSessionParams{
+		Jti:              jti,
+		UserID:           userId,
+		UserAgent:        c.Request().UserAgent(),
+		IpAddress:        clientIp(c),
+		RefreshTokenHash: refreshTokenHash,
+		ImpersonatorID:   impersonatorId,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	err = setAuthCookie(c, jti, userId, impersonatorId, isProduction)
+	if err != nil {
+		return err
+	}
+	setRefreshCookie(c, refreshToken, isProduction)
+	return nil
+}
+
+func setAuthCookie(c echo.Context, jti string, userId int32, impersonatorId pgtype.Int4, isProduction bool) error {
 	authToken, err := auth.CreateJwt(&auth.JwtOptions{
-		Subject:  strconv.Itoa(userId),
+		Id:       jti,
+		Subject:  strconv.Itoa(int(userId)),
+		Actor:    formatImpersonator(impersonatorId),
 		Audience: "go-form",
-		ValidFor: time.Hour,
+		ValidFor: accessTokenValidFor,
 	})
 	if err != nil {
 		return err
 	}
 
 	c.SetCookie(&http.Cookie{
-
 		Name:  "goform_auth",
 		Value: authToken,
-		// a week in seconds
-		MaxAge:   3600 * 24 * 7,
+		Path:  "/",
+		// The session is looked up with the expired token when refreshing
+		MaxAge:   int(sessionMaxAge.Seconds()),
 		Secure:   isProduction,
 		HttpOnly: true,
-	},
-	)
-
+		SameSite: http.SameSiteLaxMode,
+	})
 	return nil
 }
 
+// formatImpersonator formats the id of an impersonator as the subject of the `act` claim
+func formatImpersonator(impersonatorId pgtype.Int4) string {
+	if !impersonatorId.Valid {
+		return ""
+	}
+	return strconv.Itoa(int(impersonatorId.Int32))
+}
+
+func setRefreshCookie(c echo.Context, refreshToken string, isProduction bool) {
+	c.SetCookie(&http.Cookie{
+		Name:     "goform_refresh",
+		Value:    refreshToken,
+		Path:     "/",
+		MaxAge:   int(sessionMaxAge.Seconds()),
+		Secure:   isProduction,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// refreshSession reissues the auth cookie of a session with an expired token using the refresh cookie.
+// The refresh token is rotated, when an old refresh token is presented the session is revoked
+// because the token was most likely stolen.
+func refreshSession(c echo.Context, queries *database.Queries, isProduction bool) (database.GetSessionByJtiRow, error) {
+	session, err := tryGetSessionFromCookie(c, queries, true)
+	if err != nil {
+		return session, err
+	}
+	if time.Since(session.LastSeenAt) > sessionIdleTimeout {
+		return session, fmt.Errorf("session is idle since %s", session.LastSeenAt)
+	}
+	if time.Since(session.CreatedAt) > sessionMaxAge {
+		return session, fmt.Errorf("session is too old to refresh")
+	}
+	refreshCookie, err := c.Cookie("goform_refresh")
+	if err != nil {
+		return session, err
+	}
+	hash := auth.HashRefreshToken(refreshCookie.Value)
+	switch {
+	case subtle.ConstantTimeCompare([]byte(hash), []byte(session.RefreshTokenHash)) == 1:
+		refreshToken, refreshTokenHash, err := auth.GenerateRefreshToken()
+		if err != nil {
+			return session, err
+		}
+		rotated, err := queries.RotateRefreshToken(c.Request().Context(), session.Id, refreshTokenHash, hash)
+		if err != nil {
+			return session, fmt.Errorf("failed to rotate refresh token: %w", err)
+		}
+		// When a concurrent request rotated the token first, its response sets the refresh cookie
+		if rotated > 0 {
+			setRefreshCookie(c, refreshToken, isProduction)
+		}
+	case session.PreviousRefreshTokenHash != "" &&
+		subtle.ConstantTimeCompare([]byte(hash), []byte(session.PreviousRefreshTokenHash)) == 1 &&
+		time.Since(session.RefreshedAt) < refreshTokenReuseGrace:
+		// A concurrent request raced the rotation, the newer refresh cookie is already set
+	default:
+		logger.EchoWarn(c, "Refresh token reused, revoking session", slog.Int("session", int(session.Id)))
+		err = queries.DeleteSessionByJti(c.Request().Context(), session.Jti)
+		if err != nil {
+			return session, fmt.Errorf("failed to revoke session: %w", err)
+		}
+		return session, errRefreshTokenReused
+	}
+	err = setAuthCookie(c, session.Jti, session.UserID, session.ImpersonatorID, isProduction)
+	if err != nil {
+		return session, fmt.Errorf("failed to reissue token: %w", err)
+	}
+	return session, nil
+}
+
 func HandleGetUploadUrl(disk interfaces.DirectUploadDisk) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		var id uuid.UUID
@@ -436,40 +733,31 @@ func HandleResourceCreate[T any](resource resources.Resource[T]) echo.HandlerFun
 
 func HandleValidateResource[T any](resource resources.Resource[T]) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		idParam, err := strconv.Atoi(c.Param("id"))
-		var id *int
+		row := new(T)
+		id, err := strconv.Atoi(c.Param("id"))
 		if err == nil {
-			id = &idParam
-		}
-		formFields := map[string]string{}
-		formConfig := resource.FormConfig()
-		validationErrors := map[string]string{}
-		for _, field := range formConfig.Fields {
-			fieldName := field.Name()
-			formFields[fieldName] = c.QueryParam(fieldName)
-			validationError := field.Validator(formFields[fieldName])
-			if validationError != "" {
-				validationErrors[fieldName] = validationError
+			row, err = resource.FetchRow(c.Request().Context(), int32(id))
+			if err == database.ErrNotFound {
+				return c.String(404, "not found")
+			} else if err != nil {
+				return fmt.Errorf("failed to fetch row: %w", err)
 			}
 		}
-		_, err = resource.ParseRow(c.Request().Context(), id, formFields)
+		formConfig := resource.FormConfig()
+		validationErrors, err := resources.BindRow(c.Request().Context(), resource, row, c.QueryParams())
 		if err != nil {
-			if validationErr, isValidationErr := err.(resources.ValidationError); isValidationErr {
-				logger.EchoInfo(c, "Validation failed\n", slog.String("resource", resource.Title()), slog.String("reason", err.Error()))
-				validationErrors[validationErr.FieldName] = validationErr.Message
-				return c.JSON(422, map[string]any{
-					"validationErrors": validationErrors,
-				})
-
-			} else if parsingErr, isParsingErr := err.(resources.ParsingError); isParsingErr {
-				validationErrors := map[string]string{}
-				logger.EchoInfo(c, "Parsing failed\n", slog.String("resource", resource.Title()), slog.String("reason", parsingErr.Error()))
-				validationErrors[parsingErr.FieldName] = parsingErr.Message
-				return c.JSON(422, map[string]any{
-					"validationErrors": validationErrors,
-				})
+			return fmt.Errorf("failed to bind row: %w", err)
+		}
+		step, err := strconv.Atoi(c.QueryParam("step"))
+		if err == nil {
+			// Fields of later steps have not been filled in yet
+			for fieldName := range validationErrors {
+				if formConfig.StepOf(fieldName) > step {
+					delete(validationErrors, fieldName)
+				}
 			}
-		} else if len(validationErrors) > 0 {
+		}
+		if len(validationErrors) > 0 {
 			return c.JSON(422, map[string]any{
 				"validationErrors": validationErrors,
 			})
@@ -480,37 +768,26 @@ func HandleValidateResource[T any](resource resources.Resource[T]) echo.HandlerF
 	}
 }
 
-func HandleCreateResource[T any](resource resources.Resource[T]) echo.HandlerFunc {
+func HandleCreateResource[T any](queries *database.Queries, notifications resources.NotificationConfig, resource resources.Resource[T], getUser GetUserFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		formFields := map[string]string{}
-		formConfig := resource.FormConfig()
-		validationErrors := map[string]string{}
-		for _, field := range formConfig.Fields {
-			fieldName := field.Name()
-			formFields[fieldName] = c.FormValue(fieldName)
-			validationError := field.Validator(formFields[fieldName])
-			if validationError != "" {
-				validationErrors[fieldName] = validationError
-			}
+		values, err := c.FormParams()
+		if err != nil {
+			return c.String(400, "invalid form")
 		}
-		row, err := resource.ParseRow(c.Request().Context(), nil, formFields)
+		row := new(T)
+		validationErrors, err := resources.BindRow(c.Request().Context(), resource, row, values)
 		if err != nil {
-			if validationErr, ok := err.(resources.ValidationError); ok {
-				logger.EchoInfo(c, "Validation failed\n", slog.String("resource", resource.Title()), slog.String("reason", err.Error()))
-				validationErrors[validationErr.FieldName] = validationErr.Message
-			} else if parsingErr, ok := err.(resources.ParsingError); ok {
-				logger.EchoInfo(c, "Parsing failed\n", slog.String("resource", resource.Title()), slog.String("reason", parsingErr.Error()))
-				return template(c, 400, templates.ResourceCreate(resource, row, nil))
-			} else {
-				return fmt.Errorf("failed to parse row %w", err)
-			}
-
+			return fmt.Errorf("failed to bind row: %w", err)
 		}
 		if len(validationErrors) > 0 {
+			logger.EchoInfo(c, "Validation failed\n", slog.String("resource", resource.Title()), slog.Int("errors", len(validationErrors)))
+			if !isHtmx(c) {
+				return templateInLayout(c, 422, resource.Location(nil), templates.ResourceCreate(resource, row, validationErrors))
+			}
 			return template(c, 422,
 				templates.ResourceCreate(resource, row, validationErrors),
 				components.Toast(components.ToastConfig{
-					Message: "Not all fields are valid",
+					Message: i18n.T(c.Request().Context(), "Not all fields are valid"),
 					Variant: components.ToastError,
 				}),
 			)
@@ -520,52 +797,61 @@ func HandleCreateResource[T any](resource resources.Resource[T]) echo.HandlerFun
 			if err == database.ErrDuplicateEmail {
 				validationErrors := map[string]string{}
 				logger.EchoInfo(c, "Duplicate email", slog.String("resource", resource.Title()), slog.String("reason", err.Error()))
-				validationErrors["email"] = "Email already used"
+				validationErrors["email"] = i18n.T(c.Request().Context(), "Email already used")
+				if !isHtmx(c) {
+					return templateInLayout(c, 422, resource.Location(nil), templates.ResourceCreate(resource, row, validationErrors))
+				}
 				c.Response().Header().Set("hx-replace-url", fmt.Sprintf("%s/create", resource.Location(nil)))
 				return template(c, 200, templates.ResourceCreate(resource, row, validationErrors))
 			} else {
-				return fmt.Errorf("failed to create row", err)
+				return fmt.Errorf("failed to create row: %w", err)
 			}
 		}
 		logger.EchoInfo(c, "Created %s with id %d\n", slog.String("resource", resource.Title()), slog.Int("id", int(id)))
+		deleteFormDraft(c, queries, resource, 0)
+		createdRow, err := resource.FetchRow(c.Request().Context(), id)
+		if err != nil {
+			logger.EchoError(c, "failed to fetch created row", err)
+		} else {
+			notifyResourceEvent(c, queries, notifications, resource, getUser, resources.RowCreated, createdRow)
+		}
+		if !isHtmx(c) {
+			// Post/redirect/get for forms that are submitted without JavaScript
+			return c.Redirect(303, resource.Location(nil))
+		}
 		return handleResourceIndex(c, resource, components.Toast(components.ToastConfig{
-			Message: fmt.Sprintf("Sucessfully created %s", resource.Title()),
+			Message: i18n.T(c.Request().Context(), "Successfully created %s", i18n.T(c.Request().Context(), resource.Title())),
 			Variant: components.ToastSuccess,
 		}))
 	}
 }
 
-func HandleUpdateResource[T any](resource resources.Resource[T]) echo.HandlerFunc {
+func HandleUpdateResource[T any](queries *database.Queries, notifications resources.NotificationConfig, resource resources.Resource[T], getUser GetUserFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
 			return c.String(400, "invalid id")
 		}
-		formFields := map[string]string{}
-		formConfig := resource.FormConfig()
-		validationErrors := map[string]string{}
-		for _, field := range formConfig.Fields {
-			fieldName := field.Name()
-			formFields[fieldName] = c.FormValue(fieldName)
-			validationError := field.Validator(formFields[fieldName])
-			if validationError != "" {
-				validationErrors[fieldName] = validationError
-			}
+		values, err := c.FormParams()
+		if err != nil {
+			return c.String(400, "invalid form")
+		}
+		row, err := resource.FetchRow(c.Request().Context(), int32(id))
+		if err == database.ErrNotFound {
+			return template(c, 404, templates.NotFound(resource.Location(nil)))
+		} else if err != nil {
+			return fmt.Errorf("failed to fetch row: %w", err)
 		}
-		row, err := resource.ParseRow(c.Request().Context(), &id, formFields)
+		validationErrors, err := resources.BindRow(c.Request().Context(), resource, row, values)
 		if err != nil {
-			if validationErr, ok := err.(resources.ValidationError); ok {
-				logger.EchoInfo(c, "Validation failed\n", slog.String("resource", resource.Title()), slog.String("reason", err.Error()))
-				validationErrors[validationErr.FieldName] = validationErr.Reason.Error()
-			} else if parsingErr, ok := err.(resources.ParsingError); ok {
-				logger.EchoInfo(c, "Parsing failed\n", slog.String("resource", resource.Title()), slog.String("reason", parsingErr.Error()))
-				return template(c, 400, templates.ResourceCreate(resource, row, nil))
-			} else {
-				return fmt.Errorf("failed to parse row: %s", err)
-			}
+			return fmt.Errorf("failed to bind row: %w", err)
 		} else if len(validationErrors) > 0 {
+			logger.EchoInfo(c, "Validation failed\n", slog.String("resource", resource.Title()), slog.Int("errors", len(validationErrors)))
+			if !isHtmx(c) {
+				return templateInLayout(c, 422, resource.Location(nil), templates.ResourceView(resource, row, validationErrors))
+			}
 			err := triggerToast(c, ToastConfig{
-				Message: "Not all fields are valid",
+				Message: i18n.T(c.Request().Context(), "Not all fields are valid"),
 				Variant: ToastError,
 			})
 			if err != nil {
@@ -579,16 +865,129 @@ func HandleUpdateResource[T any](resource resources.Resource[T]) echo.HandlerFun
 			if err == database.ErrDuplicateEmail {
 				validationErrors := map[string]string{}
 				logger.EchoInfo(c, "Failed to update", slog.String("resource", resource.Title()), slog.String("reason", err.Error()))
-				validationErrors["email"] = "Email already used"
+				validationErrors["email"] = i18n.T(c.Request().Context(), "Email already used")
+				if !isHtmx(c) {
+					return templateInLayout(c, 422, resource.Location(nil), templates.ResourceView(resource, row, validationErrors))
+				}
 				return template(c, 200, templates.ResourceView(resource, row, validationErrors))
 			} else {
 				return fmt.Errorf("failed to update row: %w", err)
 			}
 		}
+		deleteFormDraft(c, queries, resource, int32(id))
+		notifyResourceEvent(c, queries, notifications, resource, getUser, resources.RowUpdated, row)
+		if !isHtmx(c) {
+			return c.Redirect(303, resource.Location(nil))
+		}
 		c.Response().Header().Set("hx-push-url", resource.Location(nil))
 		return handleResourceIndex(c, resource, components.Toast(components.ToastConfig{
-			Message: fmt.Sprintf("Sucessfully updated %s", resource.Title()),
+			Message: i18n.T(c.Request().Context(), "Successfully updated %s", i18n.T(c.Request().Context(), resource.Title())),
 			Variant: components.ToastSuccess,
 		}))
 	}
 }
+
+// maxFormDraftSize is the maximum size in bytes of the body of a form draft
+const maxFormDraftSize = 64 * 1024
+
+// formDraftRowId returns the id of the row that the form draft belongs to, drafts of new rows use 0
+func formDraftRowId(c echo.Context) (int32, error) {
+	if c.Param("id") == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	return int32(id), err
+}
+
+// deleteFormDraft removes the draft of a form that is saved,
+// failing to do so is logged because the row itself was saved successfully.
+func deleteFormDraft[T any](c echo.Context, queries *database.Queries, resource resources.Resource[T], rowId int32) {
+	err := queries.DeleteFormDraft(c.Request().Context(), authenticatedUserId(c), resource.Location(nil), rowId)
+	if err != nil {
+		logger.EchoError(c, "failed to delete form draft", err)
+	}
+}
+
+// notifyResourceEvent mails the users that are notified of event on row,
+// failing to do so is logged because the row itself was saved successfully.
+func notifyResourceEvent[T any](c echo.Context, queries *database.Queries, notifications resources.NotificationConfig, resource resources.Resource[T], getUser GetUserFunc, event resources.ResourceEvent, row *T) {
+	actor, err := getUser(c)
+	if err != nil {
+		logger.EchoError(c, "failed to notify users", err)
+		return
+	}
+	err = resources.Notify(c.Request().Context(), queries, notifications, resource, event, row, *actor)
+	if err != nil {
+		logger.EchoError(c, "failed to notify users", err)
+	}
+}
+
+func HandleGetFormDraft[T any](queries *database.Queries, resource resources.Resource[T]) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		rowId, err := formDraftRowId(c)
+		if err != nil {
+			return c.String(400, "invalid id")
+		}
+		draft, err := queries.GetFormDraft(c.Request().Context(), authenticatedUserId(c), resource.Location(nil), rowId)
+		if errors.Is(err, database.ErrNotFound) {
+			return c.NoContent(404)
+		} else if err != nil {
+			return fmt.Errorf("failed to get form draft: %w", err)
+		}
+		return c.JSON(200, map[string]any{
+			"fields":    json.RawMessage(draft.Fields),
+			"updatedAt": draft.UpdatedAt,
+		})
+	}
+}
+
+func HandlePutFormDraft[T any](queries *database.Queries, resource resources.Resource[T]) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		rowId, err := formDraftRowId(c)
+		if err != nil {
+			return c.String(400, "invalid id")
+		}
+		var body struct {
+			Fields map[string]any `json:"fields"`
+		}
+		err = json.NewDecoder(http.MaxBytesReader(c.Response(), c.Request().Body, maxFormDraftSize)).Decode(&body)
+		if err != nil || body.Fields == nil {
+			return c.String(400, "invalid draft")
+		}
+		// Only fields of the form are stored
+		fields := map[string]any{}
+		for _, field := range resource.FormConfig().Fields {
+			if value, ok := body.Fields[field.Name()]; ok {
+				fields[field.Name()] = value
+			}
+		}
+		encodedFields, err := json.Marshal(fields)
+		if err != nil {
+			return fmt.Errorf("failed to encode form draft: %w", err)
+		}
+		err = queries.UpsertFormDraft(c.Request().Context(), database.UpsertFormDraftParams{
+			UserID:   authenticatedUserId(c),
+			Resource: resource.Location(nil),
+			RowID:    rowId,
+			Fields:   encodedFields,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save form draft: %w", err)
+		}
+		return c.NoContent(204)
+	}
+}
+
+func HandleDeleteFormDraft[T any](queries *database.Queries, resource resources.Resource[T]) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		rowId, err := formDraftRowId(c)
+		if err != nil {
+			return c.String(400, "invalid id")
+		}
+		err = queries.DeleteFormDraft(c.Request().Context(), authenticatedUserId(c), resource.Location(nil), rowId)
+		if err != nil {
+			return fmt.Errorf("failed to delete form draft: %w", err)
+		}
+		return c.NoContent(204)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/Kavantix/go-form/templates"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+)
+
+// impersonate handles a request of an admin acting as the user of session.
+// The account of the user cannot be changed and every other change is recorded in the audit log with both identities.
+func impersonate(c echo.Context, queries *database.Queries, session database.GetSessionByJtiRow, next echo.HandlerFunc) error {
+	c.Set("ImpersonatorId", session.ImpersonatorID.Int32)
+	c.SetRequest(c.Request().WithContext(context.WithValue(c.Request().Context(), "impersonatedEmail", session.Email)))
+	logger.EchoWithAttrs(c, slog.Int("impersonator", int(session.ImpersonatorID.Int32)), slog.Int("user", int(session.UserID)))
+
+	method := c.Request().Method
+	if method == http.MethodGet || method == http.MethodHead {
+		return next(c)
+	}
+	var err error
+	if strings.HasPrefix(c.Path(), "/account/") {
+		logger.EchoInfo(c, "Account change while impersonating denied", slog.String("path", c.Path()))
+		if isHtmx(c) {
+			c.Response().Header().Set("HX-Retarget", "body")
+			c.Response().Header().Set("HX-Reswap", "outerHTML")
+		}
+		err = template(c, 403, templates.ImpersonationForbidden("/users"))
+	} else {
+		err = next(c)
+	}
+	status := c.Response().Status
+	if err != nil {
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			status = httpErr.Code
+		} else {
+			status = 500
+		}
+	}
+	auditErr := recordAuditLog(c, queries, session.ImpersonatorID, session.UserID, status)
+	if auditErr != nil {
+		logger.EchoError(c, "Failed to record impersonated request", auditErr)
+	}
+	return err
+}
+
+// recordAuditLog records the request of actorId on behalf of userId in the audit log
+func recordAuditLog(c echo.Context, queries *database.Queries, actorId pgtype.Int4, userId int32, status int) error {
+	return queries.InsertAuditLog(c.Request().Context(), database.InsertAuditLogParams{
+		ActorID: actorId,
+		UserID:  pgtype.Int4{Int32: userId, Valid: true},
+		Method:  c.Request().Method,
+		Path:    c.Request().URL.Path,
+		Status:  int32(status),
+	})
+}
+
+// impersonatorId returns the id of the admin that acts as the user that is logged in
+func impersonatorId(c echo.Context) (int32, bool) {
+	id, ok := c.Get("ImpersonatorId").(int32)
+	return id, ok
+}
+
+// endExpiredImpersonation ends session when the impersonation in it expired and sends the admin to the login.
+// Relogging in would mail the impersonated user and log the admin in as that user,
+// so the admin logs in as themselves and starts impersonating again.
+func endExpiredImpersonation(c echo.Context, queries *database.Queries, session database.GetSessionByJtiRow) error {
+	err := queries.DeleteSessionByJti(c.Request().Context(), session.Jti)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	clearUserLoggedInCookie(c)
+	logger.EchoInfo(c, "Impersonation expired", slog.Int("impersonator", int(session.ImpersonatorID.Int32)), slog.Int("user", int(session.UserID)))
+	return htmxRedirect(c, "/login")
+}
+
+func HandleStartImpersonation(isProduction bool, queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.String(400, "invalid id")
+		}
+		adminId := authenticatedUserId(c)
+		user, err := queries.GetUser(c.Request().Context(), int32(userId))
+		if errors.Is(err, database.ErrNotFound) {
+			return template(c, 404, templates.NotFound("/admin/sessions"))
+		} else if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if user.Id == adminId || user.Role == database.RoleAdmin || user.Status != database.UserStatusActive {
+			logger.EchoInfo(c, "Impersonation denied", slog.Int("user", userId))
+			return template(c, 403, templates.ImpersonationForbidden("/admin/sessions"))
+		}
+		// The admin session is ended so the admin is never logged in twice in the same browser
+		_, err = queries.DeleteSession(c.Request().Context(), authenticatedSessionId(c))
+		if err != nil {
+			return fmt.Errorf("failed to end admin session: %w", err)
+		}
+		impersonator := pgtype.Int4{Int32: adminId, Valid: true}
+		err = startSession(c, queries, user.Id, impersonator, isProduction)
+		if err != nil {
+			return err
+		}
+		err = recordAuditLog(c, queries, impersonator, user.Id, 303)
+		if err != nil {
+			return fmt.Errorf("failed to record impersonation: %w", err)
+		}
+		logger.EchoInfo(c, "Started impersonating user", slog.Int("user", userId))
+		if isHtmx(c) {
+			return htmxRedirect(c, "/users")
+		}
+		return c.Redirect(303, "/users")
+	}
+}
+
+func HandleStopImpersonation(isProduction bool, queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		adminId, ok := impersonatorId(c)
+		if !ok {
+			return template(c, 404, templates.NotFound("/users"))
+		}
+		_, err := queries.DeleteSession(c.Request().Context(), authenticatedSessionId(c))
+		if err != nil {
+			return fmt.Errorf("failed to end impersonation session: %w", err)
+		}
+		err = setUserLoggedInCookie(c, queries, adminId, isProduction)
+		if err != nil {
+			return err
+		}
+		logger.EchoInfo(c, "Stopped impersonating user")
+		if isHtmx(c) {
+			return htmxRedirect(c, "/admin/sessions")
+		}
+		return c.Redirect(303, "/admin/sessions")
+	}
+}
@@ -1,16 +1,166 @@
 package interfaces
 
-import "github.com/a-h/templ"
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/templ"
+)
 
 type FormConfig[T any] struct {
 	SaveUrl func(row *T) string
 	Fields  [](FormField[T])
+	// Steps optionally splits the form into multiple steps that are filled in one after another
+	Steps []FormStep
+}
+
+// FormStep is a single step of a multi-step form
+type FormStep struct {
+	Title      string
+	FieldNames []string
+}
+
+// StepOf returns the index of the step that contains the field with fieldName,
+// fields that are not part of any step belong to the last step.
+// Indexed names of repeater items resolve to the step of the repeater field.
+func (c FormConfig[T]) StepOf(fieldName string) int {
+	if end := strings.IndexByte(fieldName, '['); end >= 0 {
+		fieldName = fieldName[:end]
+	}
+	for i, step := range c.Steps {
+		for _, name := range step.FieldNames {
+			if name == fieldName {
+				return i
+			}
+		}
+	}
+	return max(len(c.Steps)-1, 0)
+}
+
+// StepFields returns the fields that belong to the step at index
+func (c FormConfig[T]) StepFields(index int) [](FormField[T]) {
+	fields := [](FormField[T]){}
+	for _, field := range c.Fields {
+		if c.StepOf(field.Name()) == index {
+			fields = append(fields, field)
+		}
+	}
+	return fields
 }
 
 type FormField[T any] interface {
 	Name() string
 	Label() string
-	RenderFormField(form FormConfig[T], value *T) templ.Component
+	// RenderFormField renders the field with the server side validation errors of the form,
+	// so they are shown when JavaScript is not available.
+	RenderFormField(form FormConfig[T], value *T, validationErrors map[string]string) templ.Component
 	Value(row *T) string
-	Validator(value string) string
+	// Validator returns the validation error of value translated to the locale of ctx, or an empty string when it is valid
+	Validator(ctx context.Context, value string) string
+	// Bind parses the value of the field from formFields and assigns it into row.
+	// Invalid input is reported with a ParsingError.
+	Bind(row *T, formFields map[string]string) error
+}
+
+type ValidationError struct {
+	FieldName string
+	Message   string
+	Reason    error
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("Validation of field '%s' failed with error: %s", e.FieldName, e.Reason.Error())
+}
+
+type ParsingError struct {
+	FieldName string
+	Reason    error
+	Message   string
+}
+
+func (e ParsingError) Error() string {
+	return fmt.Sprintf("Parsing of field '%s' failed with error: %s", e.FieldName, e.Reason.Error())
+}
+
+// RepeatedFormField is a FormField that holds a list of items which all consist of the same sub fields.
+// The sub fields of every item are submitted with indexed names, see RepeaterKey.
+// The value of the field itself is the number of items.
+type RepeatedFormField[T any] interface {
+	FormField[T]
+	SubFieldNames() []string
+	Items(row *T) []map[string]string
+	// ValidateItem returns the validation errors of a single item keyed by sub field name
+	ValidateItem(ctx context.Context, item map[string]string) map[string]string
+}
+
+// RepeaterSkipBlankItems is submitted as the value of a repeater field by forms that do not use JavaScript.
+// These forms always submit a few blank items to fill in, which are skipped when parsing.
+const RepeaterSkipBlankItems = "skip_blank"
+
+// RepeaterKey returns the name under which a sub field of the item at index is submitted,
+// for example `answer_options[1].label`.
+func RepeaterKey(fieldName string, index int, subFieldName string) string {
+	return fmt.Sprintf("%s[%d].%s", fieldName, index, subFieldName)
+}
+
+// ParseRepeaterItems collects the items of the repeater field with fieldName from submitted values.
+// Items are returned ordered by their index, gaps in the indices are skipped.
+// Blank items are skipped as well when the value of the field is RepeaterSkipBlankItems.
+func ParseRepeaterItems(values url.Values, fieldName string, subFieldNames []string) []map[string]string {
+	prefix := fieldName + "["
+	// Only the submitted indices are visited, so a huge index cannot make parsing slow
+	seen := map[int]bool{}
+	indices := []int{}
+	for key := range values {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		rest := key[len(prefix):]
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			continue
+		}
+		index, err := strconv.Atoi(rest[:end])
+		if err == nil && index >= 0 && !seen[index] {
+			seen[index] = true
+			indices = append(indices, index)
+		}
+	}
+	slices.Sort(indices)
+	skipBlank := values.Get(fieldName) == RepeaterSkipBlankItems
+	items := []map[string]string{}
+	for _, i := range indices {
+		item := map[string]string{}
+		found := false
+		blank := true
+		for _, subFieldName := range subFieldNames {
+			key := RepeaterKey(fieldName, i, subFieldName)
+			if _, ok := values[key]; ok {
+				found = true
+			}
+			item[subFieldName] = values.Get(key)
+			blank = blank && item[subFieldName] == ""
+		}
+		if found && !(skipBlank && blank) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// RepeaterItems reads the items of the repeater field with fieldName back from parsed form fields.
+func RepeaterItems(formFields map[string]string, fieldName string, subFieldNames []string) []map[string]string {
+	count, _ := strconv.Atoi(formFields[fieldName])
+	items := make([]map[string]string, count)
+	for i := range items {
+		items[i] = map[string]string{}
+		for _, subFieldName := range subFieldNames {
+			items[i][subFieldName] = formFields[RepeaterKey(fieldName, i, subFieldName)]
+		}
+	}
+	return items
 }
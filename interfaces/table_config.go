@@ -1,14 +1,17 @@
 package interfaces
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"runtime/debug"
 )
 
 type ColumnConfig[T any] struct {
+	// Label is translated when the table is rendered
 	Label string
-	Value func(row T) string
+	// Value returns the value of the column for row, translated to the locale of ctx
+	Value func(ctx context.Context, row T) string
 	Url   func(row T) string
 }
 
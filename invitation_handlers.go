@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kavantix/go-form/auth"
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/mails"
+	"github.com/Kavantix/go-form/pkg/baseurl"
+	"github.com/Kavantix/go-form/pkg/i18n"
+	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/Kavantix/go-form/templates"
+	"github.com/Kavantix/go-form/templates/components"
+	"github.com/a-h/templ"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+)
+
+// invitationValidFor is how long an invitation can be accepted, resending it starts a new period
+const invitationValidFor = time.Hour * 24 * 7
+
+// maxUserFieldLength is the length of the name and email columns of the users table
+const maxUserFieldLength = 255
+
+// sendInvitation mails a link to accept the invitation with jti to user
+func sendInvitation(c echo.Context, invitedBy string, user database.DisplayableUser, jti string, expiresAt time.Time) error {
+	token, err := auth.CreateJwt(&auth.JwtOptions{
+		Id:       jti,
+		Audience: "invitation",
+		Subject:  strconv.Itoa(int(user.Id)),
+		ValidFor: time.Until(expiresAt),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create token: %w", err)
+	}
+	return mails.Invitation(mails.InvitationMailContent{
+		User:      user,
+		InvitedBy: invitedBy,
+		Link:      baseurl.Url("/invitation", url.Values{"token": {token}}),
+		ExpiresAt: expiresAt,
+		Locale:    mailLocale(c, user),
+	}).SendTo(c.Request().Context(), user.Email)
+}
+
+// invitationSentToast reports whether the invitation mail to email could be sent
+func invitationSentToast(c echo.Context, email string, sendErr error) components.ToastConfig {
+	if sendErr != nil {
+		// The invitation is saved, so it can be resent from the list
+		logger.EchoError(c, "Failed to send invitation", sendErr)
+		return components.ToastConfig{
+			Message: i18n.T(c.Request().Context(), "The invitation to %s could not be sent, please resend it", email),
+			Variant: components.ToastError,
+		}
+	}
+	return components.ToastConfig{
+		Message: i18n.T(c.Request().Context(), "Invitation sent to %s", email),
+		Variant: components.ToastSuccess,
+	}
+}
+
+func renderAdminInvitations(c echo.Context, queries *database.Queries, code int, form templates.InvitationForm, toasts ...components.ToastConfig) error {
+	invitations, err := queries.GetPendingInvitations(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get invitations: %w", err)
+	}
+	form.Roles = database.Roles
+	if form.Role == "" {
+		form.Role = database.RoleUser
+	}
+	templatesToRender := []templ.Component{
+		templates.AdminInvitations(invitations, form),
+	}
+	for _, toast := range toasts {
+		templatesToRender = append(templatesToRender, components.Toast(toast))
+	}
+	return template(c, code, templatesToRender...)
+}
+
+func HandleAdminInvitations(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return renderAdminInvitations(c, queries, 200, templates.InvitationForm{})
+	}
+}
+
+// validateUserDetails returns the validation errors of the details that invited users can confirm,
+// translated to the locale of ctx
+func validateUserDetails(ctx context.Context, name, dateOfBirth string) (time.Time, map[string]string) {
+	validationErrors := map[string]string{}
+	if name == "" {
+		validationErrors["name"] = i18n.T(ctx, "Name is required")
+	} else if len(name) > maxUserFieldLength {
+		validationErrors["name"] = i18n.T(ctx, "Name can be at most %d characters", maxUserFieldLength)
+	}
+	parsedDateOfBirth, err := time.Parse("2006-01-02", dateOfBirth)
+	if err != nil {
+		validationErrors["date_of_birth"] = i18n.T(ctx, "Enter a valid date")
+	} else if parsedDateOfBirth.After(time.Now()) {
+		validationErrors["date_of_birth"] = i18n.T(ctx, "Date of birth cannot be in the future")
+	}
+	return parsedDateOfBirth, validationErrors
+}
+
+func HandleCreateInvitation(queries *database.Queries, getUser GetUserFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		admin, err := getUser(c)
+		if err != nil {
+			return err
+		}
+		form := templates.InvitationForm{
+			Name:        strings.TrimSpace(c.FormValue("name")),
+			Email:       strings.TrimSpace(c.FormValue("email")),
+			DateOfBirth: c.FormValue("date_of_birth"),
+			Role:        c.FormValue("role"),
+		}
+		dateOfBirth, validationErrors := validateUserDetails(c.Request().Context(), form.Name, form.DateOfBirth)
+		if _, err := mail.ParseAddress(form.Email); err != nil || len(form.Email) > maxUserFieldLength {
+			validationErrors["email"] = i18n.T(c.Request().Context(), "Enter a valid email address")
+		}
+		if !slices.Contains(database.Roles, form.Role) {
+			validationErrors["role"] = i18n.T(c.Request().Context(), "Unknown role")
+		}
+		if len(validationErrors) > 0 {
+			form.ValidationErrors = validationErrors
+			return renderAdminInvitations(c, queries, 422, form)
+		}
+		userId, err := queries.InsertInvitedUser(c.Request().Context(), database.InsertInvitedUserParams{
+			Name:        form.Name,
+			Email:       form.Email,
+			DateOfBirth: dateOfBirth,
+			Role:        form.Role,
+		})
+		if errors.Is(err, database.ErrDuplicateEmail) {
+			form.ValidationErrors = map[string]string{"email": i18n.T(c.Request().Context(), "Email already used")}
+			return renderAdminInvitations(c, queries, 422, form)
+		} else if err != nil {
+			return fmt.Errorf("failed to create invited user: %w", err)
+		}
+		jti := uuid.New().String()
+		expiresAt := time.Now().Add(invitationValidFor)
+		_, err = queries.InsertInvitation(c.Request().Context(), database.InsertInvitationParams{
+			Jti:       jti,
+			UserID:    userId,
+			InvitedBy: pgtype.Int4{Int32: admin.Id, Valid: true},
+			ExpiresAt: expiresAt,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save invitation: %w", err)
+		}
+		logger.EchoInfo(c, "Admin invited user", slog.Int("user", int(userId)))
+		user := database.DisplayableUser{Id: userId, Name: form.Name, Email: form.Email}
+		sendErr := sendInvitation(c, admin.Name, user, jti, expiresAt)
+		if !isHtmx(c) {
+			return c.Redirect(303, "/admin/invitations")
+		}
+		return renderAdminInvitations(c, queries, 200, templates.InvitationForm{}, invitationSentToast(c, form.Email, sendErr))
+	}
+}
+
+func HandleResendInvitation(queries *database.Queries, getUser GetUserFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		admin, err := getUser(c)
+		if err != nil {
+			return err
+		}
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.String(400, "invalid id")
+		}
+		invitation, err := queries.GetPendingInvitation(c.Request().Context(), int32(id))
+		if errors.Is(err, database.ErrNotFound) {
+			return template(c, 404, templates.NotFound("/admin/invitations"))
+		} else if err != nil {
+			return fmt.Errorf("failed to get invitation: %w", err)
+		}
+		// The previous link can no longer be used
+		jti := uuid.New().String()
+		expiresAt := time.Now().Add(invitationValidFor)
+		renewed, err := queries.RenewInvitation(c.Request().Context(), invitation.Id, jti, expiresAt)
+		if err != nil {
+			return fmt.Errorf("failed to renew invitation: %w", err)
+		}
+		if renewed == 0 {
+			return template(c, 404, templates.NotFound("/admin/invitations"))
+		}
+		logger.EchoInfo(c, "Admin resent invitation", slog.Int("invitation", id))
+		user := database.DisplayableUser{Id: invitation.UserID, Name: invitation.Name, Email: invitation.Email}
+		sendErr := sendInvitation(c, admin.Name, user, jti, expiresAt)
+		if !isHtmx(c) {
+			return c.Redirect(303, "/admin/invitations")
+		}
+		return renderAdminInvitations(c, queries, 200, templates.InvitationForm{}, invitationSentToast(c, invitation.Email, sendErr))
+	}
+}
+
+// HandleRevokeInvitation removes the invited user, so the email can be invited again
+func HandleRevokeInvitation(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.String(400, "invalid id")
+		}
+		deleted, err := queries.DeleteInvitedUser(c.Request().Context(), int32(id))
+		if err != nil {
+			return fmt.Errorf("failed to revoke invitation: %w", err)
+		}
+		if deleted == 0 {
+			return template(c, 404, templates.NotFound("/admin/invitations"))
+		}
+		logger.EchoInfo(c, "Admin revoked invitation", slog.Int("invitation", id))
+		if !isHtmx(c) {
+			return c.Redirect(303, "/admin/invitations")
+		}
+		return renderAdminInvitations(c, queries, 200, templates.InvitationForm{}, components.ToastConfig{
+			Message: i18n.T(c.Request().Context(), "Invitation revoked"),
+			Variant: components.ToastSuccess,
+		})
+	}
+}
+
+// parseInvitation returns the id of the invitation in tokenString and the user it was created for,
+// when the invitation cannot be used the error page is rendered and ok is false.
+func parseInvitation(c echo.Context, tokenString string) (jti string, userId int32, ok bool, err error) {
+	claims, err := auth.ParseJwt(tokenString)
+	if errors.Is(err, auth.ErrTokenExpired) {
+		logger.EchoInfo(c, "Expired invitation")
+		return "", 0, false, template(c, 410, templates.InvitationError(i18n.T(c.Request().Context(), "This invitation has expired.")))
+	} else if err != nil {
+		logger.EchoWarn(c, "Invalid invitation token", slog.String("reason", err.Error()))
+		return "", 0, false, template(c, 400, templates.InvitationError(i18n.T(c.Request().Context(), "This invitation is invalid.")))
+	}
+	jti, _ = claims["jti"].(string)
+	rawUserId, _ := claims["sub"].(string)
+	parsedUserId, err := strconv.Atoi(rawUserId)
+	if claims["aud"] != "invitation" || jti == "" || err != nil {
+		logger.EchoWarn(c, "Invalid invitation token missing claims")
+		return "", 0, false, template(c, 400, templates.InvitationError(i18n.T(c.Request().Context(), "This invitation is invalid.")))
+	}
+	return jti, int32(parsedUserId), true, nil
+}
+
+// renderUnusableInvitation renders the error page for an invitation that could not be accepted
+func renderUnusableInvitation(c echo.Context, invitation database.GetInvitationByJtiRow, err error) error {
+	if errors.Is(err, database.ErrNotFound) {
+		// Resent and revoked invitations are no longer found
+		logger.EchoWarn(c, "Unknown invitation")
+		return template(c, 400, templates.InvitationError(i18n.T(c.Request().Context(), "This invitation is no longer valid.")))
+	} else if err != nil {
+		return fmt.Errorf("failed to get invitation: %w", err)
+	}
+	if invitation.AcceptedAt.Valid {
+		return template(c, 410, templates.InvitationError(i18n.T(c.Request().Context(), "This invitation was already accepted.")))
+	}
+	return template(c, 410, templates.InvitationError(i18n.T(c.Request().Context(), "This invitation has expired.")))
+}
+
+// HandleInvitation shows the first-login page where invited users confirm their details
+func HandleInvitation(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tokenString := c.QueryParam("token")
+		jti, _, ok, err := parseInvitation(c, tokenString)
+		if !ok {
+			return err
+		}
+		invitation, err := queries.GetInvitationByJti(c.Request().Context(), jti)
+		if err != nil || invitation.AcceptedAt.Valid || time.Now().After(invitation.ExpiresAt) {
+			return renderUnusableInvitation(c, invitation, err)
+		}
+		return template(c, 200, templates.AcceptInvitation(templates.AcceptInvitationForm{
+			Token:       tokenString,
+			Email:       invitation.Email,
+			Name:        invitation.Name,
+			DateOfBirth: invitation.DateOfBirth.Format("2006-01-02"),
+		}))
+	}
+}
+
+func HandlePostInvitation(isProduction bool, queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tokenString := c.FormValue("token")
+		jti, userId, ok, err := parseInvitation(c, tokenString)
+		if !ok {
+			return err
+		}
+		form := templates.AcceptInvitationForm{
+			Token:       tokenString,
+			Name:        strings.TrimSpace(c.FormValue("name")),
+			DateOfBirth: c.FormValue("date_of_birth"),
+		}
+		dateOfBirth, validationErrors := validateUserDetails(c.Request().Context(), form.Name, form.DateOfBirth)
+		if len(validationErrors) > 0 {
+			invitation, err := queries.GetInvitationByJti(c.Request().Context(), jti)
+			if err != nil {
+				return renderUnusableInvitation(c, invitation, err)
+			}
+			form.Email = invitation.Email
+			form.ValidationErrors = validationErrors
+			return template(c, 422, templates.AcceptInvitation(form))
+		}
+		acceptedUserId, err := queries.AcceptInvitation(c.Request().Context(), jti, form.Name, dateOfBirth)
+		if errors.Is(err, database.ErrNotFound) {
+			invitation, err := queries.GetInvitationByJti(c.Request().Context(), jti)
+			return renderUnusableInvitation(c, invitation, err)
+		} else if err != nil {
+			return fmt.Errorf("failed to accept invitation: %w", err)
+		}
+		if acceptedUserId != userId {
+			logger.EchoWarn(c, "Invitation user does not match token", slog.Int("user", int(userId)))
+			return template(c, 400, templates.InvitationError(i18n.T(c.Request().Context(), "This invitation is invalid.")))
+		}
+		logger.EchoInfo(c, "User accepted invitation", slog.Int("user", int(userId)))
+		return startLoginSession(c, queries, userId, isProduction)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/pkg/i18n"
+	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/Kavantix/go-form/templates"
+	"github.com/Kavantix/go-form/templates/components"
+	"github.com/a-h/templ"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+)
+
+// setLocale translates the response to the locale that matches the Accept-Language header best,
+// the preference of the user that is logged in overrides it, see setPreferredLocale.
+func setLocale(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		locale := i18n.Negotiate(c.Request().Header.Get("Accept-Language"))
+		c.Response().Header().Add("Vary", "Accept-Language")
+		c.SetRequest(c.Request().WithContext(i18n.WithLocale(c.Request().Context(), locale)))
+		return next(c)
+	}
+}
+
+// setPreferredLocale translates the response to preferred when it is set to a supported locale
+func setPreferredLocale(c echo.Context, preferred pgtype.Text) {
+	if preferred.Valid && i18n.Supported(preferred.String) {
+		c.SetRequest(c.Request().WithContext(i18n.WithLocale(c.Request().Context(), preferred.String)))
+	}
+}
+
+// mailLocale returns the locale to send mails to user in,
+// users without a preference get mails in the locale of the request.
+func mailLocale(c echo.Context, user database.DisplayableUser) string {
+	if user.Locale.Valid && i18n.Supported(user.Locale.String) {
+		return user.Locale.String
+	}
+	return i18n.FromContext(c.Request().Context())
+}
+
+func renderAccountPreferences(c echo.Context, queries *database.Queries, toasts ...components.ToastConfig) error {
+	user, err := queries.GetUser(c.Request().Context(), authenticatedUserId(c))
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	templatesToRender := []templ.Component{
+		templates.AccountPreferences(user.Locale.String),
+	}
+	for _, toast := range toasts {
+		templatesToRender = append(templatesToRender, components.Toast(toast))
+	}
+	return template(c, 200, templatesToRender...)
+}
+
+func HandleAccountPreferences(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return renderAccountPreferences(c, queries)
+	}
+}
+
+func HandlePostAccountPreferences(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		locale := c.FormValue("locale")
+		if locale != "" && !i18n.Supported(locale) {
+			return c.String(400, "unsupported locale")
+		}
+		preferred := pgtype.Text{String: locale, Valid: locale != ""}
+		err := queries.UpdateUserLocale(c.Request().Context(), authenticatedUserId(c), preferred)
+		if err != nil {
+			return fmt.Errorf("failed to save locale: %w", err)
+		}
+		logger.EchoInfo(c, "Updated preferences", slog.String("locale", locale))
+		if !isHtmx(c) {
+			return c.Redirect(303, "/account/preferences")
+		}
+		if !preferred.Valid {
+			preferred = pgtype.Text{String: i18n.Negotiate(c.Request().Header.Get("Accept-Language")), Valid: true}
+		}
+		// The page is shown in the new language right away
+		setPreferredLocale(c, preferred)
+		return renderAccountPreferences(c, queries, components.ToastConfig{
+			Message: i18n.T(c.Request().Context(), "Preferences saved"),
+			Variant: components.ToastSuccess,
+		})
+	}
+}
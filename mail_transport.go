@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/mails"
+	"github.com/wneessen/go-mail"
+)
+
+// defaultSmtpPorts are the ports mail servers usually listen on for each tls mode
+var defaultSmtpPorts = map[mails.SmtpTls]string{
+	mails.SmtpTlsNone:     "25",
+	mails.SmtpTlsStartTls: "587",
+	mails.SmtpTlsImplicit: "465",
+}
+
+func ResolveMailTransport(
+	LookupEnv func(key, fallback string) string,
+	MustLookupEnv func(key string) string,
+	queries *database.Queries,
+) mails.Transport {
+	mailTransport := LookupEnv("MAIL_TRANSPORT", "smtp")
+	switch mailTransport {
+	case "smtp":
+		tls := mails.SmtpTls(LookupEnv("SMTP_TLS", string(mails.SmtpTlsStartTls)))
+		port, err := strconv.Atoi(LookupEnv("SMTP_PORT", defaultSmtpPorts[tls]))
+		if err != nil {
+			log.Fatalf("Invalid SMTP_PORT: %s\n", err)
+		}
+		transport, err := mails.NewSmtpTransport(mails.SmtpConfig{
+			Host:     MustLookupEnv("SMTP_HOST"),
+			Port:     port,
+			Tls:      tls,
+			Auth:     mail.SMTPAuthType(strings.ToUpper(LookupEnv("SMTP_AUTH", string(mail.SMTPAuthPlain)))),
+			Username: LookupEnv("SMTP_USERNAME", ""),
+			Password: LookupEnv("SMTP_PASSWORD", ""),
+		})
+		if err != nil {
+			log.Fatal(fmt.Errorf("Failed to create smtp transport: %w", err))
+		}
+		return transport
+	case "maildir":
+		transport, err := mails.NewMaildirTransport(LookupEnv("MAILDIR_PATH", "./storage/mail"))
+		if err != nil {
+			log.Fatal(fmt.Errorf("Failed to create maildir transport: %w", err))
+		}
+		return transport
+	case "memory":
+		return mails.NewMemoryTransport()
+	case "catcher":
+		return mails.NewCatcherTransport(queries)
+	default:
+		log.Fatalf("MAIL_TRANSPORT '%s' is not supported, supported: (smtp/maildir/memory/catcher)", mailTransport)
+		return nil
+	}
+}
@@ -0,0 +1,47 @@
+package mails
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Kavantix/go-form/database"
+)
+
+// CatcherQueries are the queries of the caught_mails table, implemented by database.Queries
+type CatcherQueries interface {
+	InsertCaughtMail(ctx context.Context, arg database.InsertCaughtMailParams) (int32, error)
+}
+
+// CatcherTransport keeps messages in the caught_mails table instead of delivering them,
+// so they can be read at /dev/mails during development
+type CatcherTransport struct {
+	queries CatcherQueries
+}
+
+func NewCatcherTransport(queries CatcherQueries) *CatcherTransport {
+	return &CatcherTransport{queries: queries}
+}
+
+func (t *CatcherTransport) Send(ctx context.Context, message Message) error {
+	// The addresses are validated like the other transports do
+	_, err := toMsg(message)
+	if err != nil {
+		return err
+	}
+	cc := message.Cc
+	if cc == nil {
+		cc = []string{}
+	}
+	_, err = t.queries.InsertCaughtMail(ctx, database.InsertCaughtMailParams{
+		FromAddress: message.From,
+		ToAddresses: message.To,
+		CcAddresses: cc,
+		Subject:     message.Subject,
+		PlainText:   message.PlainText,
+		Html:        message.Html,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to catch message: %w", err)
+	}
+	return nil
+}
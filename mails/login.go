@@ -1,63 +1,115 @@
 package mails
 
 import (
-	"fmt"
+	"time"
 
 	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/pkg/i18n"
 	"github.com/matcornic/hermes/v2"
 )
 
 type LoginMailContent struct {
 	User database.DisplayableUser
 	Link string
+	// ExpiresAt is when the link expires, the mail is not delivered after it
+	ExpiresAt time.Time
+	Locale    string
 }
 
 func Login(content LoginMailContent) *Email {
+	locale := content.Locale
 	return &Email{
-		subject: "Your login link to go-form",
-		body: hermesBody(hermes.Email{
+		subject: i18n.Tr(locale, "Your login link to go-form"),
+		body: hermesBody(locale, hermes.Email{
 			Body: hermes.Body{
 				Name: content.User.Name,
 				Intros: []string{
-					"Welcome to go-form! We're very excited to have you on board.",
+					i18n.Tr(locale, "Welcome to go-form! We're very excited to have you on board."),
 				},
 
 				Actions: []hermes.Action{
 					{
-						Instructions: "To get started with go-form, please click here:",
+						Instructions: i18n.Tr(locale, "To get started with go-form, please click here:"),
 						Button: hermes.Button{
 							Color: "#646EE4", // Optional action button color
-							Text:  "Login",
+							Text:  i18n.Tr(locale, "Login"),
 							Link:  content.Link,
 						},
 					},
 				},
 				Outros: []string{
-					"Need help, or have questions? Just reply to this email, we'd love to help.",
+					i18n.Tr(locale, "Need help, or have questions? Just reply to this email, we'd love to help."),
 				},
 			},
 		}),
+		expiresAt: content.ExpiresAt,
 	}
 }
 
 type ReloginMailContent struct {
 	User  database.DisplayableUser
 	Token string
+	// ExpiresAt is when the token expires, the mail is not delivered after it
+	ExpiresAt time.Time
+	Locale    string
 }
 
 func Relogin(content ReloginMailContent) *Email {
+	locale := content.Locale
 	return &Email{
-		subject: fmt.Sprintf("Your go-form login token: %s", content.Token),
-		body: hermesBody(hermes.Email{
+		subject: i18n.Tr(locale, "Your go-form login token: %s", content.Token),
+		body: hermesBody(locale, hermes.Email{
 			Body: hermes.Body{
 				Name: content.User.Name,
 				Actions: []hermes.Action{
 					{
-						Instructions: "Enter this token in the validation field:",
+						Instructions: i18n.Tr(locale, "Enter this token in the validation field:"),
 						InviteCode:   content.Token,
 					},
 				},
 			},
 		}),
+		expiresAt: content.ExpiresAt,
+	}
+}
+
+type InvitationMailContent struct {
+	User      database.DisplayableUser
+	InvitedBy string
+	Link      string
+	ExpiresAt time.Time
+	Locale    string
+}
+
+func Invitation(content InvitationMailContent) *Email {
+	locale := content.Locale
+	intro := i18n.Tr(locale, "You have been invited to go-form.")
+	if content.InvitedBy != "" {
+		intro = i18n.Tr(locale, "%s has invited you to go-form.", content.InvitedBy)
+	}
+	return &Email{
+		subject: i18n.Tr(locale, "You are invited to go-form"),
+		body: hermesBody(locale, hermes.Email{
+			Body: hermes.Body{
+				Name: content.User.Name,
+				Intros: []string{
+					intro,
+				},
+				Actions: []hermes.Action{
+					{
+						Instructions: i18n.Tr(locale, "To accept the invitation and set up your account, please click here:"),
+						Button: hermes.Button{
+							Color: "#646EE4",
+							Text:  i18n.Tr(locale, "Accept invitation"),
+							Link:  content.Link,
+						},
+					},
+				},
+				Outros: []string{
+					i18n.Tr(locale, "This invitation expires on %s.", i18n.FormatDate(locale, content.ExpiresAt)),
+				},
+			},
+		}),
+		expiresAt: content.ExpiresAt,
 	}
 }
@@ -0,0 +1,54 @@
+package mails
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaildirTransport writes messages to a maildir so they can be read with a mail client during development
+type MaildirTransport struct {
+	dir string
+}
+
+func NewMaildirTransport(dir string) (*MaildirTransport, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		err := os.MkdirAll(filepath.Join(dir, sub), 0o755)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create maildir: %w", err)
+		}
+	}
+	return &MaildirTransport{dir: dir}, nil
+}
+
+// Send writes message to tmp first and then moves it to new, so readers never see partial messages
+func (t *MaildirTransport) Send(ctx context.Context, message Message) error {
+	m, err := toMsg(message)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d.%s.go-form.eml", time.Now().Unix(), uuid.New().String())
+	tmpPath := filepath.Join(t.dir, "tmp", name)
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create message file: %w", err)
+	}
+	_, err = m.WriteTo(file)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	err = os.Rename(tmpPath, filepath.Join(t.dir, "new", name))
+	if err != nil {
+		return fmt.Errorf("failed to deliver message: %w", err)
+	}
+	return nil
+}
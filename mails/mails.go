@@ -3,12 +3,13 @@ package mails
 import (
 	"context"
 	"fmt"
-	"log"
+	netmail "net/mail"
 	"time"
 
+	"github.com/Kavantix/go-form/pkg/baseurl"
+	"github.com/Kavantix/go-form/pkg/i18n"
 	"github.com/getsentry/sentry-go"
 	"github.com/matcornic/hermes/v2"
-	"github.com/wneessen/go-mail"
 )
 
 type Email struct {
@@ -17,50 +18,55 @@ type Email struct {
 		plainText string
 		html      string
 	}
+	// expiresAt is when the mail is no longer useful, like the login link in it expiring
+	expiresAt time.Time
 }
 
-var mailClient *mail.Client
+func (message *Email) Subject() string {
+	return message.subject
+}
+
+func (message *Email) PlainText() string {
+	return message.body.plainText
+}
+
+func (message *Email) Html() string {
+	return message.body.html
+}
+
+var (
+	transport Transport
+	sender    string
+)
 
-func Init(mailhogHost string) error {
-	var err error
-	mailClient, err = mail.NewClient(
-		mailhogHost,
-		// mail.WithPort(25),
-		mail.WithPort(1025),
-		// mail.WithSMTPAuth(mail.SMTPAuthPlain),
-		// mail.WithUsername("my_username"),
-		// mail.WithPassword("extremely_secret_pass"),
-		mail.WithTLSPolicy(mail.NoTLS),
-	)
+// Init configures mails to be delivered with mailTransport and sent from the address from
+func Init(mailTransport Transport, from string) error {
+	_, err := netmail.ParseAddress(from)
 	if err != nil {
-		return fmt.Errorf("failed to create mail client: %w", err)
+		return fmt.Errorf("invalid sender `%s`: %w", from, err)
 	}
+	transport = mailTransport
+	sender = from
+	h.Product.Link = baseurl.Url("/", nil)
 	return nil
 }
 
 func (message *Email) SendTo(ctx context.Context, toEmail string, ccEmails ...string) error {
 	span := sentry.StartSpan(ctx, "function", sentry.WithDescription("Send email"))
 	defer span.Finish()
-	m := mail.NewMsg()
-	if err := m.From("noreply@go-form.test"); err != nil {
-		log.Fatalf("failed to set From address: %s", err)
-	}
-	if err := m.To(toEmail); err != nil {
-		log.Fatalf("failed to set To address: %s", err)
-	}
-	if len(ccEmails) > 0 {
-		if err := m.Cc(ccEmails...); err != nil {
-			log.Fatalf("failed to set Cc address: %s", err)
-		}
-	}
-	m.Subject(message.subject)
-	m.SetBodyString(mail.TypeTextPlain, message.body.plainText)
-	m.AddAlternativeString(mail.TypeTextHTML, message.body.html)
-	if err := mailClient.DialAndSend(m); err != nil {
+	err := transport.Send(span.Context(), Message{
+		From:      sender,
+		To:        []string{toEmail},
+		Cc:        ccEmails,
+		Subject:   message.subject,
+		PlainText: message.body.plainText,
+		Html:      message.body.html,
+		ExpiresAt: message.expiresAt,
+	})
+	if err != nil {
 		span.Status = sentry.SpanStatusFailedPrecondition
 		return fmt.Errorf("failed to send mail: %w", err)
 	}
-
 	return nil
 }
 
@@ -69,14 +75,23 @@ var h = &hermes.Hermes{
 	// Optional Theme
 	// Theme: new(Default)
 	Product: hermes.Product{
-		// Appears in header & footer of e-mails
-		Name:      "go-form",
-		Link:      "http://go-form.test/",
-		Copyright: fmt.Sprintf("Copyright © %v Pieter van Loon. All rights reserved.", time.Now().Year()),
+		// Appears in header & footer of e-mails, the link is set to the base url in Init
+		Name: "go-form",
 	},
 }
 
-func hermesBody(email hermes.Email) struct{ plainText, html string } {
+// hermesFor returns the hermes configuration with the footer of the mail translated to locale
+func hermesFor(locale string) *hermes.Hermes {
+	localized := *h
+	localized.Product.Copyright = i18n.Tr(locale, "Copyright © %v Pieter van Loon. All rights reserved.", time.Now().Year())
+	localized.Product.TroubleText = i18n.Tr(locale, "If you’re having trouble with the button '{ACTION}', copy and paste the URL below into your web browser.")
+	return &localized
+}
+
+func hermesBody(locale string, email hermes.Email) struct{ plainText, html string } {
+	email.Body.Greeting = i18n.Tr(locale, "Hi")
+	email.Body.Signature = i18n.Tr(locale, "Yours truly")
+	h := hermesFor(locale)
 	textBody, err := h.GeneratePlainText(email)
 	if err != nil {
 		// The only thing that could fail would be configuration error
@@ -0,0 +1,43 @@
+package mails
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// MemoryTransport keeps the messages it sends in memory, for tests
+type MemoryTransport struct {
+	mutex    sync.Mutex
+	messages []Message
+}
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+func (t *MemoryTransport) Send(ctx context.Context, message Message) error {
+	// The addresses are validated like the other transports do
+	_, err := toMsg(message)
+	if err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.messages = append(t.messages, message)
+	return nil
+}
+
+// Messages returns the messages that were sent, oldest first
+func (t *MemoryTransport) Messages() []Message {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return slices.Clone(t.messages)
+}
+
+// Reset forgets the messages that were sent
+func (t *MemoryTransport) Reset() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.messages = nil
+}
@@ -0,0 +1,99 @@
+package mails
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/pkg/i18n"
+	"github.com/matcornic/hermes/v2"
+)
+
+// NotificationTemplate is the content of a notification mail.
+// Every text is translated and then executed as a text/template with NotificationData,
+// the templates can use `formatDate` and `t` to format dates and translate values in the locale of the mail.
+type NotificationTemplate struct {
+	Subject string
+	Intro   string
+	// Button is the text of the button that links to the row, the button is left out when it is empty
+	Button string
+	Outro  string
+}
+
+// NotificationData is available in the texts of a NotificationTemplate
+type NotificationData struct {
+	// User is the recipient of the mail
+	User database.DisplayableUser
+	// Actor is the name of the user that made the change
+	Actor string
+	Row   any
+}
+
+type NotificationMailContent struct {
+	Template NotificationTemplate
+	Data     NotificationData
+	Link     string
+	Locale   string
+}
+
+// Notification renders content.Template, it fails when one of its texts is not a valid template for content.Data
+func Notification(content NotificationMailContent) (*Email, error) {
+	locale := content.Locale
+	texts := []string{content.Template.Subject, content.Template.Intro, content.Template.Button, content.Template.Outro}
+	for i, text := range texts {
+		executed, err := executeNotificationText(locale, text, content.Data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid notification template `%s`: %w", text, err)
+		}
+		texts[i] = executed
+	}
+	subject, intro, button, outro := texts[0], texts[1], texts[2], texts[3]
+	body := hermes.Body{
+		Name: content.Data.User.Name,
+	}
+	if intro != "" {
+		body.Intros = []string{intro}
+	}
+	if button != "" {
+		body.Actions = []hermes.Action{
+			{
+				Button: hermes.Button{
+					Color: "#646EE4",
+					Text:  button,
+					Link:  content.Link,
+				},
+			},
+		}
+	}
+	if outro != "" {
+		body.Outros = []string{outro}
+	}
+	return &Email{
+		subject: subject,
+		body:    hermesBody(locale, hermes.Email{Body: body}),
+	}, nil
+}
+
+func executeNotificationText(locale, text string, data NotificationData) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	tmpl, err := template.New("notification").
+		Option("missingkey=error").
+		Funcs(template.FuncMap{
+			"formatDate": func(t time.Time) string { return i18n.FormatDate(locale, t) },
+			"t":          func(message string) string { return i18n.Tr(locale, message) },
+		}).
+		Parse(i18n.Tr(locale, text))
+	if err != nil {
+		return "", err
+	}
+	var executed strings.Builder
+	err = tmpl.Execute(&executed, data)
+	if err != nil {
+		return "", err
+	}
+	return executed.String(), nil
+}
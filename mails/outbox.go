@@ -0,0 +1,190 @@
+package mails
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// OutboxQueries are the queries of the outbox table, implemented by database.Queries
+type OutboxQueries interface {
+	InsertOutboxMessage(ctx context.Context, arg database.InsertOutboxMessageParams) (int32, error)
+	ClaimOutboxMessages(ctx context.Context, leaseUntil time.Time, maxMessages int32) ([]database.Outbox, error)
+	MarkOutboxMessageSent(ctx context.Context, id int32) error
+	MarkOutboxMessageFailed(ctx context.Context, arg database.MarkOutboxMessageFailedParams) error
+	DeleteSentOutboxMessages(ctx context.Context, sentAt pgtype.Timestamp) (int64, error)
+}
+
+// OutboxTransport stores messages in the outbox table, an OutboxWorker delivers them
+type OutboxTransport struct {
+	queries OutboxQueries
+}
+
+func NewOutboxTransport(queries OutboxQueries) *OutboxTransport {
+	return &OutboxTransport{queries: queries}
+}
+
+func (t *OutboxTransport) Send(ctx context.Context, message Message) error {
+	// Invalid addresses are rejected right away instead of failing every delivery attempt
+	_, err := toMsg(message)
+	if err != nil {
+		return err
+	}
+	cc := message.Cc
+	if cc == nil {
+		cc = []string{}
+	}
+	_, err = t.queries.InsertOutboxMessage(ctx, database.InsertOutboxMessageParams{
+		FromAddress: message.From,
+		ToAddresses: message.To,
+		CcAddresses: cc,
+		Subject:     message.Subject,
+		PlainText:   message.PlainText,
+		Html:        message.Html,
+		ExpiresAt:   pgtype.Timestamp{Time: message.ExpiresAt, Valid: !message.ExpiresAt.IsZero()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to queue message: %w", err)
+	}
+	return nil
+}
+
+const (
+	// outboxPollInterval is how often the outbox is checked for messages to deliver
+	outboxPollInterval = time.Second * 5
+	// outboxBatchSize is the most messages that are claimed at once
+	outboxBatchSize = 20
+	// outboxLease is how long a claimed message is not claimed again,
+	// so messages of a worker that stopped during delivery are retried
+	outboxLease = time.Minute * 5
+	// outboxMaxAttempts is how often delivery is attempted before the message is marked as failed
+	outboxMaxAttempts = 8
+	// outboxSentRetention is how long sent messages are kept for the admin view
+	outboxSentRetention = time.Hour * 24 * 30
+	// outboxCleanupInterval is how often sent messages past the retention are deleted
+	outboxCleanupInterval = time.Hour
+)
+
+// OutboxWorker delivers the messages in the outbox with transport.
+// Multiple workers can run at the same time, every message is claimed by one of them.
+type OutboxWorker struct {
+	queries     OutboxQueries
+	transport   Transport
+	lastCleanup time.Time
+}
+
+func NewOutboxWorker(queries OutboxQueries, transport Transport) *OutboxWorker {
+	return &OutboxWorker{
+		queries:   queries,
+		transport: transport,
+	}
+}
+
+// Run delivers messages until ctx is done
+func (w *OutboxWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+	for {
+		err := w.deliverPending(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			logger.Error(ctx, "Failed to deliver outbox", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverPending delivers batches of messages until none are due
+func (w *OutboxWorker) deliverPending(ctx context.Context) error {
+	if time.Since(w.lastCleanup) > outboxCleanupInterval {
+		deleted, err := w.queries.DeleteSentOutboxMessages(ctx, pgtype.Timestamp{Time: time.Now().Add(-outboxSentRetention), Valid: true})
+		if err != nil {
+			return fmt.Errorf("failed to delete sent messages: %w", err)
+		}
+		if deleted > 0 {
+			logger.Info(ctx, "Deleted sent outbox messages", slog.Int64("messages", deleted))
+		}
+		w.lastCleanup = time.Now()
+	}
+	for {
+		messages, err := w.queries.ClaimOutboxMessages(ctx, time.Now().Add(outboxLease), outboxBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to claim messages: %w", err)
+		}
+		for _, message := range messages {
+			err := w.deliver(ctx, message)
+			if err != nil {
+				return err
+			}
+		}
+		if len(messages) < outboxBatchSize {
+			return nil
+		}
+	}
+}
+
+func (w *OutboxWorker) deliver(ctx context.Context, message database.Outbox) error {
+	// A login link or token that expired cannot be used anymore, so it is not delivered late
+	if message.ExpiresAt.Valid && !time.Now().Before(message.ExpiresAt.Time) {
+		logger.Warn(ctx, "Outbox message expired before delivery", slog.Int("message", int(message.Id)), slog.Int("attempts", int(message.Attempts)))
+		err := w.queries.MarkOutboxMessageFailed(ctx, database.MarkOutboxMessageFailedParams{
+			Id:            message.Id,
+			Status:        database.OutboxStatusFailed,
+			LastError:     pgtype.Text{String: "expired before it could be delivered", Valid: true},
+			NextAttemptAt: time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to mark message %d as expired: %w", message.Id, err)
+		}
+		return nil
+	}
+	sendErr := w.transport.Send(ctx, Message{
+		From:      message.FromAddress,
+		To:        message.ToAddresses,
+		Cc:        message.CcAddresses,
+		Subject:   message.Subject,
+		PlainText: message.PlainText,
+		Html:      message.Html,
+		ExpiresAt: message.ExpiresAt.Time,
+	})
+	if sendErr == nil {
+		err := w.queries.MarkOutboxMessageSent(ctx, message.Id)
+		if err != nil {
+			return fmt.Errorf("failed to mark message %d as sent: %w", message.Id, err)
+		}
+		return nil
+	}
+	status := database.OutboxStatusPending
+	if message.Attempts >= outboxMaxAttempts {
+		status = database.OutboxStatusFailed
+		logger.Error(ctx, "Giving up on outbox message", sendErr, slog.Int("message", int(message.Id)), slog.Int("attempts", int(message.Attempts)))
+	} else {
+		logger.Warn(ctx, "Failed to deliver outbox message", slog.Int("message", int(message.Id)), slog.String("reason", sendErr.Error()))
+	}
+	err := w.queries.MarkOutboxMessageFailed(ctx, database.MarkOutboxMessageFailedParams{
+		Id:            message.Id,
+		Status:        status,
+		LastError:     pgtype.Text{String: sendErr.Error(), Valid: true},
+		NextAttemptAt: time.Now().Add(outboxBackoff(message.Attempts)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark message %d as failed: %w", message.Id, err)
+	}
+	return nil
+}
+
+// outboxBackoff is how long to wait before the next delivery attempt after the given number of attempts failed,
+// it doubles every attempt starting at half a minute
+func outboxBackoff(attempts int32) time.Duration {
+	return time.Duration(float64(time.Second*30) * math.Pow(2, float64(attempts-1)))
+}
@@ -0,0 +1,96 @@
+package mails
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Kavantix/go-form/database"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// memoryOutbox implements OutboxQueries without a database, every pending message is due right away
+type memoryOutbox struct {
+	messages []database.Outbox
+}
+
+func (o *memoryOutbox) InsertOutboxMessage(ctx context.Context, arg database.InsertOutboxMessageParams) (int32, error) {
+	id := int32(len(o.messages) + 1)
+	o.messages = append(o.messages, database.Outbox{
+		Id:          id,
+		FromAddress: arg.FromAddress,
+		ToAddresses: arg.ToAddresses,
+		CcAddresses: arg.CcAddresses,
+		Subject:     arg.Subject,
+		PlainText:   arg.PlainText,
+		Html:        arg.Html,
+		Status:      database.OutboxStatusPending,
+		ExpiresAt:   arg.ExpiresAt,
+	})
+	return id, nil
+}
+
+func (o *memoryOutbox) ClaimOutboxMessages(ctx context.Context, leaseUntil time.Time, maxMessages int32) ([]database.Outbox, error) {
+	claimed := []database.Outbox{}
+	for i := range o.messages {
+		message := &o.messages[i]
+		if message.Status != database.OutboxStatusPending || message.NextAttemptAt.After(time.Now()) || len(claimed) == int(maxMessages) {
+			continue
+		}
+		message.Attempts++
+		message.NextAttemptAt = leaseUntil
+		claimed = append(claimed, *message)
+	}
+	return claimed, nil
+}
+
+func (o *memoryOutbox) MarkOutboxMessageSent(ctx context.Context, id int32) error {
+	o.messages[id-1].Status = database.OutboxStatusSent
+	return nil
+}
+
+func (o *memoryOutbox) MarkOutboxMessageFailed(ctx context.Context, arg database.MarkOutboxMessageFailedParams) error {
+	message := &o.messages[arg.Id-1]
+	message.Status = arg.Status
+	message.LastError = arg.LastError
+	message.NextAttemptAt = arg.NextAttemptAt
+	return nil
+}
+
+func (o *memoryOutbox) DeleteSentOutboxMessages(ctx context.Context, sentAt pgtype.Timestamp) (int64, error) {
+	return 0, nil
+}
+
+func TestOutboxDoesNotDeliverExpiredMessages(t *testing.T) {
+	ctx := context.Background()
+	outbox := &memoryOutbox{}
+	transport := NewMemoryTransport()
+	queue := NewOutboxTransport(outbox)
+	message := Message{
+		From:      "noreply@go-form.test",
+		To:        []string{"jane@example.com"},
+		Subject:   "Your login link to go-form",
+		PlainText: "Login",
+		Html:      "<p>Login</p>",
+	}
+	for _, expiresAt := range []time.Time{{}, time.Now().Add(time.Minute), time.Now().Add(-time.Second)} {
+		message.ExpiresAt = expiresAt
+		err := queue.Send(ctx, message)
+		if err != nil {
+			t.Fatalf("failed to queue message: %s", err)
+		}
+	}
+
+	err := NewOutboxWorker(outbox, transport).deliverPending(ctx)
+	if err != nil {
+		t.Fatalf("failed to deliver: %s", err)
+	}
+	if delivered := len(transport.Messages()); delivered != 2 {
+		t.Errorf("delivered %d messages, expected the 2 that did not expire", delivered)
+	}
+	for i, expectedStatus := range []string{database.OutboxStatusSent, database.OutboxStatusSent, database.OutboxStatusFailed} {
+		if status := outbox.messages[i].Status; status != expectedStatus {
+			t.Errorf("message %d is %s, expected %s", i+1, status, expectedStatus)
+		}
+	}
+}
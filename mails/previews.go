@@ -0,0 +1,86 @@
+package mails
+
+import (
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/pkg/baseurl"
+)
+
+var previewUser = database.DisplayableUser{
+	Id:          1,
+	Name:        "Jane Doe",
+	Email:       "jane@example.com",
+	DateOfBirth: time.Date(1990, time.March, 14, 0, 0, 0, 0, time.UTC),
+	Role:        database.RoleUser,
+	Status:      database.UserStatusActive,
+}
+
+// previews render every mail with sample data in a locale, by name
+var previews = map[string]func(locale string) *Email{
+	"login": func(locale string) *Email {
+		return Login(LoginMailContent{
+			User:   previewUser,
+			Link:   baseurl.Url("/loginlink", url.Values{"token": {"preview"}}),
+			Locale: locale,
+		})
+	},
+	"relogin": func(locale string) *Email {
+		return Relogin(ReloginMailContent{
+			User:   previewUser,
+			Token:  "123456",
+			Locale: locale,
+		})
+	},
+	"invitation": func(locale string) *Email {
+		return Invitation(InvitationMailContent{
+			User:      previewUser,
+			InvitedBy: "John Admin",
+			Link:      baseurl.Url("/invitation", url.Values{"token": {"preview"}}),
+			ExpiresAt: time.Now().Add(time.Hour * 24 * 7),
+			Locale:    locale,
+		})
+	},
+	"notification": func(locale string) *Email {
+		mail, err := Notification(NotificationMailContent{
+			Template: NotificationTemplate{
+				Subject: "Your account was updated",
+				Intro:   "{{.Actor}} updated the details of your account, your date of birth is now {{formatDate .Row.DateOfBirth}}.",
+				Outro:   "If you did not expect this change, please contact an admin.",
+			},
+			Data: NotificationData{
+				User:  previewUser,
+				Actor: "John Admin",
+				Row:   previewUser,
+			},
+			Link:   baseurl.Url("/users/1", nil),
+			Locale: locale,
+		})
+		if err != nil {
+			// The template of the preview is fixed, so this is a programming error
+			panic(err)
+		}
+		return mail
+	},
+}
+
+// PreviewNames returns the names of the mails that can be previewed, sorted
+func PreviewNames() []string {
+	names := make([]string, 0, len(previews))
+	for name := range previews {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Preview renders the mail called name with sample data in locale
+func Preview(name, locale string) (*Email, bool) {
+	preview, ok := previews[name]
+	if !ok {
+		return nil, false
+	}
+	return preview(locale), true
+}
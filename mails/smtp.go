@@ -0,0 +1,80 @@
+package mails
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wneessen/go-mail"
+)
+
+type SmtpTls string
+
+const (
+	// SmtpTlsNone sends mail unencrypted, only use it for local servers
+	SmtpTlsNone SmtpTls = "none"
+	// SmtpTlsStartTls upgrades the connection with STARTTLS and fails when the server does not support it
+	SmtpTlsStartTls SmtpTls = "starttls"
+	// SmtpTlsImplicit connects with TLS right away, usually on port 465
+	SmtpTlsImplicit SmtpTls = "tls"
+)
+
+type SmtpConfig struct {
+	Host string
+	Port int
+	Tls  SmtpTls
+	// Auth is the SMTP authentication mechanism, no authentication is used when the username is empty
+	Auth     mail.SMTPAuthType
+	Username string
+	Password string
+}
+
+// SmtpTransport delivers messages to an SMTP server
+type SmtpTransport struct {
+	options []mail.Option
+	host    string
+}
+
+func NewSmtpTransport(config SmtpConfig) (*SmtpTransport, error) {
+	options := []mail.Option{mail.WithPort(config.Port)}
+	switch config.Tls {
+	case SmtpTlsNone:
+		options = append(options, mail.WithTLSPolicy(mail.NoTLS))
+	case SmtpTlsStartTls:
+		options = append(options, mail.WithTLSPolicy(mail.TLSMandatory))
+	case SmtpTlsImplicit:
+		options = append(options, mail.WithSSL())
+	default:
+		return nil, fmt.Errorf("unknown tls mode `%s`", config.Tls)
+	}
+	if config.Username != "" {
+		switch config.Auth {
+		case mail.SMTPAuthPlain, mail.SMTPAuthLogin, mail.SMTPAuthCramMD5:
+		default:
+			return nil, fmt.Errorf("unsupported authentication mechanism `%s`", config.Auth)
+		}
+		options = append(options,
+			mail.WithSMTPAuth(config.Auth),
+			mail.WithUsername(config.Username),
+			mail.WithPassword(config.Password),
+		)
+	}
+	// Validate the options once so configuration errors surface at startup
+	_, err := mail.NewClient(config.Host, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mail client: %w", err)
+	}
+	return &SmtpTransport{options: options, host: config.Host}, nil
+}
+
+func (t *SmtpTransport) Send(ctx context.Context, message Message) error {
+	m, err := toMsg(message)
+	if err != nil {
+		return err
+	}
+	// A client holds a single connection, so every message gets its own
+	client, err := mail.NewClient(t.host, t.options...)
+	if err != nil {
+		return fmt.Errorf("failed to create mail client: %w", err)
+	}
+	return client.DialAndSendWithContext(ctx, m)
+}
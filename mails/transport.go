@@ -0,0 +1,46 @@
+package mails
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/wneessen/go-mail"
+)
+
+// Message is an email that is ready to be delivered by a Transport
+type Message struct {
+	From      string
+	To        []string
+	Cc        []string
+	Subject   string
+	PlainText string
+	Html      string
+	// ExpiresAt is when the message should no longer be delivered, it never expires when it is zero
+	ExpiresAt time.Time
+}
+
+// Transport delivers messages to their recipients
+type Transport interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// toMsg converts message to a mime message
+func toMsg(message Message) (*mail.Msg, error) {
+	m := mail.NewMsg()
+	if err := m.From(message.From); err != nil {
+		return nil, fmt.Errorf("invalid From address: %w", err)
+	}
+	if err := m.To(message.To...); err != nil {
+		return nil, fmt.Errorf("invalid To address: %w", err)
+	}
+	if len(message.Cc) > 0 {
+		if err := m.Cc(message.Cc...); err != nil {
+			return nil, fmt.Errorf("invalid Cc address: %w", err)
+		}
+	}
+	m.Subject(message.Subject)
+	m.SetBodyString(mail.TypeTextPlain, message.PlainText)
+	m.AddAlternativeString(mail.TypeTextHTML, message.Html)
+	return m, nil
+}
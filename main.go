@@ -9,13 +9,18 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/Kavantix/go-form/auth"
 	"github.com/Kavantix/go-form/database"
 	"github.com/Kavantix/go-form/mails"
+	"github.com/Kavantix/go-form/pkg/baseurl"
 	"github.com/Kavantix/go-form/pkg/env"
 	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/Kavantix/go-form/pkg/ratelimit"
+	"github.com/Kavantix/go-form/resources"
+	"github.com/Kavantix/go-form/templates"
 	"github.com/getsentry/sentry-go"
 	sentryhttp "github.com/getsentry/sentry-go/http"
 	"github.com/joho/godotenv"
@@ -50,13 +55,28 @@ func main() {
 		log.Fatalf("Cannot initialize sentry:\n%s\n", err)
 	}
 
-	err = auth.LoadKeys(MustLookupEnv("PRIVATE_KEY"), MustLookupEnv("PUBLIC_KEY"))
+	err = baseurl.Init(MustLookupEnv("BASE_URL"))
+	if err != nil {
+		log.Fatalf("Failed to configure base url:\n%s\n", err)
+	}
+
+	err = auth.LoadKeyring(LookupEnv("SIGNING_KEYS_DIR", "keys"), MustLookupEnv("SIGNING_KEY_ID"))
 	if err != nil {
 		log.Fatalf("Failed to load keys:\n%s\n", err)
 	}
 
-	mailhogHost := MustLookupEnv("MAILHOG_HOST")
-	err = mails.Init(mailhogHost)
+	err = auth.LoadTotpEncryptionKey(MustLookupEnv("TOTP_ENCRYPTION_KEY"))
+	if err != nil {
+		log.Fatalf("Failed to load totp encryption key:\n%s\n", err)
+	}
+
+	err = auth.InitPasskeys(
+		LookupEnv("WEBAUTHN_RP_ID", baseurl.Hostname()),
+		strings.Split(LookupEnv("WEBAUTHN_ORIGINS", baseurl.Origin()), ","),
+	)
+	if err != nil {
+		log.Fatalf("Failed to configure passkeys:\n%s\n", err)
+	}
 
 	disk := ResolveDisk(LookupEnv, MustLookupEnv)
 	queries, err := database.Connect(
@@ -75,8 +95,24 @@ func main() {
 	// 	IncludeValues: false,
 	// })
 
+	mailTransport := ResolveMailTransport(LookupEnv, MustLookupEnv, queries)
+	senderTransport := mailTransport
+	if LookupEnv("MAIL_OUTBOX", "true") == "true" {
+		// Mails are queued in the database and delivered in the background
+		go mails.NewOutboxWorker(queries, mailTransport).Run(ctx)
+		senderTransport = mails.NewOutboxTransport(queries)
+	}
+	err = mails.Init(senderTransport, LookupEnv("MAIL_FROM", fmt.Sprintf("go-form <noreply@%s>", baseurl.Hostname())))
+	if err != nil {
+		log.Fatalf("Failed to configure mails:\n%s\n", err)
+	}
+
 	logger.Info(ctx, "Configuring routes...")
 	r := echo.New()
+	r.IPExtractor, err = ResolveIPExtractor(LookupEnv("TRUSTED_PROXIES", ""))
+	if err != nil {
+		log.Fatalf("Failed to configure trusted proxies: %s\n", err)
+	}
 	if isProduction {
 		logger.SetupEchoGoogleCloudLogger(r, env.Lookup("PROJECT_ID", "eighth-gamma-414620"))
 	}
@@ -105,18 +141,42 @@ func main() {
 		}
 	})
 
-	RegisterMailhogProxy(
-		r,
-		MailhogHost(mailhogHost),
-		MailhogUser(MustLookupEnv("MAILHOG_USER")),
-		MailhogPassword(MustLookupEnv("MAILHOG_PASSWORD")),
-	)
+	// The dev mails can read every mail that was sent, so they need to be enabled explicitly
+	isLocal := LookupEnv("ENVIRONMENT", "") == "local"
+	_, catcherEnabled := mailTransport.(*mails.CatcherTransport)
+	if catcherEnabled && !isLocal {
+		log.Fatalf("The mail catcher can only be used when ENVIRONMENT is local\n")
+	}
+
+	var oidcProvider *auth.OidcProvider
+	if issuer := LookupEnv("OIDC_ISSUER", ""); issuer != "" {
+		oidcProvider = auth.NewOidcProvider(auth.OidcConfig{
+			Issuer:       issuer,
+			ClientId:     MustLookupEnv("OIDC_CLIENT_ID"),
+			ClientSecret: LookupEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectUrl:  LookupEnv("OIDC_REDIRECT_URL", baseurl.Url("/login/oidc/callback", nil)),
+
+			AllowMissingEmailVerified: LookupEnv("OIDC_ALLOW_MISSING_EMAIL_VERIFIED", "false") == "true",
+		})
+		templates.OidcProviderName = LookupEnv("OIDC_PROVIDER_NAME", "single sign-on")
+	}
+
+	notifications, err := resources.ParseNotificationConfig(LookupEnv("NOTIFICATIONS", ""))
+	if err != nil {
+		log.Fatalf("Failed to configure notifications:\n%s\n", err)
+	}
 
 	RegisterRoutes(
 		r,
 		disk,
 		isProduction,
 		queries,
+		oidcProvider,
+		OidcAutoProvision(LookupEnv("OIDC_AUTO_PROVISION", "false") == "true"),
+		ratelimit.NewLimiter(ResolveRateLimitStore(queries)),
+		ResolveSecurityHeadersConfig(isProduction),
+		DevMails{Enabled: isLocal, CatcherEnabled: catcherEnabled},
+		notifications,
 	)
 
 	host := env.Lookup("HOST", "0.0.0.0")
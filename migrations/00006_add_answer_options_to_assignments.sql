@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table assignments
+  add answer_options jsonb default '[]' not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table assignments
+  drop column answer_options;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists form_drafts (
+  id serial primary key,
+  user_id integer references users(id) on delete cascade not null,
+  resource varchar(255) not null,
+  row_id integer default 0 not null,
+  fields jsonb not null,
+  created_at timestamp default now() not null,
+  updated_at timestamp default now() not null,
+  unique (user_id, resource, row_id)
+);
+CREATE TRIGGER form_drafts_updated_at
+    BEFORE UPDATE ON form_drafts
+    FOR EACH ROW
+    EXECUTE PROCEDURE updated_at_trigger();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop trigger if exists form_drafts_updated_at on form_drafts;
+drop table if exists form_drafts;
+-- +goose StatementEnd
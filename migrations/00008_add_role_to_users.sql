@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table users
+  add role varchar(50) default 'user' not null;
+-- the first user becomes the admin
+update users set role = 'admin' where id = (select min(id) from users);
+create or replace view displayable_users as
+SELECT 
+  id, name, email, date_of_birth, role
+FROM users;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop view if exists displayable_users;
+create view displayable_users as
+SELECT 
+  id, name, email, date_of_birth
+FROM users;
+alter table users
+  drop column role;
+-- +goose StatementEnd
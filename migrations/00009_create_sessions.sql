@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists sessions (
+  id serial primary key,
+  jti varchar(64) not null unique,
+  user_id integer references users(id) on delete cascade not null,
+  user_agent text default '' not null,
+  ip_address varchar(45) default '' not null,
+  created_at timestamp default now() not null,
+  last_seen_at timestamp default now() not null
+);
+create index sessions_user_id on sessions (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists sessions_user_id;
+drop table if exists sessions;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table sessions
+  add refresh_token_hash varchar(64) default '' not null,
+  add previous_refresh_token_hash varchar(64) default '' not null,
+  add refreshed_at timestamp default now() not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table sessions
+  drop column refresh_token_hash,
+  drop column previous_refresh_token_hash,
+  drop column refreshed_at;
+-- +goose StatementEnd
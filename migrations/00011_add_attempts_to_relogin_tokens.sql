@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table relogin_tokens
+  add attempts integer default 0 not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table relogin_tokens
+  drop column attempts;
+-- +goose StatementEnd
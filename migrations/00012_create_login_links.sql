@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists login_links (
+  id serial primary key,
+  jti varchar(64) not null unique,
+  user_id integer references users(id) on delete cascade not null,
+  created_at timestamp default now() not null,
+  expires_at timestamp not null,
+  used_at timestamp
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists login_links;
+-- +goose StatementEnd
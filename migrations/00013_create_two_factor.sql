@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists user_totps (
+  user_id integer primary key references users(id) on delete cascade,
+  secret_encrypted text not null,
+  created_at timestamp default now() not null,
+  confirmed_at timestamp
+);
+create table if not exists recovery_codes (
+  id serial primary key,
+  user_id integer references users(id) on delete cascade not null,
+  code_hash varchar(64) not null,
+  created_at timestamp default now() not null,
+  used_at timestamp,
+  unique (user_id, code_hash)
+);
+create table if not exists role_settings (
+  role varchar(50) primary key,
+  require_two_factor boolean default false not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists role_settings;
+drop table if exists recovery_codes;
+drop table if exists user_totps;
+-- +goose StatementEnd
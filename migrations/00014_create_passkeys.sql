@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists passkeys (
+  id serial primary key,
+  user_id integer references users(id) on delete cascade not null,
+  name varchar(100) not null,
+  credential_id bytea not null unique,
+  credential jsonb not null,
+  created_at timestamp default now() not null,
+  last_used_at timestamp
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists passkeys;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists api_tokens (
+  id serial primary key,
+  user_id integer references users(id) on delete cascade not null,
+  name varchar(100) not null,
+  token_hash varchar(64) not null unique,
+  scopes text[] not null default '{}',
+  created_at timestamp default now() not null,
+  last_used_at timestamp,
+  expires_at timestamp
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists api_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists rate_limits (
+  key text primary key,
+  hits integer not null,
+  reset_at timestamp not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists rate_limits;
+-- +goose StatementEnd
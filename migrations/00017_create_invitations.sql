@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table users
+  add status varchar(50) default 'active' not null;
+create or replace view displayable_users as
+SELECT 
+  id, name, email, date_of_birth, role, status
+FROM users;
+create table if not exists invitations (
+  id serial primary key,
+  jti varchar(64) not null unique,
+  user_id integer references users(id) on delete cascade not null,
+  invited_by integer references users(id) on delete set null,
+  created_at timestamp default now() not null,
+  expires_at timestamp not null,
+  accepted_at timestamp
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists invitations;
+drop view if exists displayable_users;
+create view displayable_users as
+SELECT 
+  id, name, email, date_of_birth, role
+FROM users;
+alter table users
+  drop column status;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table sessions
+  add impersonator_id integer references users(id) on delete cascade;
+create table if not exists audit_log (
+  id serial primary key,
+  actor_id integer references users(id) on delete set null,
+  user_id integer references users(id) on delete set null,
+  method varchar(10) not null,
+  path text not null,
+  status integer not null,
+  created_at timestamp default now() not null
+);
+create index audit_log_user_id on audit_log (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index if exists audit_log_user_id;
+drop table if exists audit_log;
+alter table sessions
+  drop column impersonator_id;
+-- +goose StatementEnd
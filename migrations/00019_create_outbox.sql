@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists outbox (
+  id serial primary key,
+  from_address text not null,
+  to_addresses text[] not null,
+  cc_addresses text[] not null,
+  subject text not null,
+  plain_text text not null,
+  html text not null,
+  status varchar(50) default 'pending' not null,
+  attempts integer default 0 not null,
+  next_attempt_at timestamp default now() not null,
+  last_error text,
+  created_at timestamp default now() not null,
+  sent_at timestamp
+);
+create index outbox_pending on outbox(next_attempt_at) where status = 'pending';
+create index outbox_created_at on outbox(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists outbox;
+-- +goose StatementEnd
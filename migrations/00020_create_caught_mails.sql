@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists caught_mails (
+  id serial primary key,
+  from_address text not null,
+  to_addresses text[] not null,
+  cc_addresses text[] not null,
+  subject text not null,
+  plain_text text not null,
+  html text not null,
+  created_at timestamp default now() not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists caught_mails;
+-- +goose StatementEnd
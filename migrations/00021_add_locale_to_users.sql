@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table users
+  add locale varchar(10);
+create or replace view displayable_users as
+SELECT 
+  id, name, email, date_of_birth, role, status, locale
+FROM users;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop view if exists displayable_users;
+create view displayable_users as
+SELECT 
+  id, name, email, date_of_birth, role, status
+FROM users;
+alter table users
+  drop column locale;
+-- +goose StatementEnd
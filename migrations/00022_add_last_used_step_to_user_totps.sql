@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table user_totps
+  add last_used_step bigint default 0 not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table user_totps
+  drop column last_used_step;
+-- +goose StatementEnd
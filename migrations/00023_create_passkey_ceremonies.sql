@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+create table if not exists passkey_ceremonies (
+  id varchar(64) primary key,
+  state text not null,
+  expires_at timestamp not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table if exists passkey_ceremonies;
+-- +goose StatementEnd
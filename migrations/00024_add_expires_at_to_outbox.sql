@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table outbox
+  add expires_at timestamp;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table outbox
+  drop column expires_at;
+-- +goose StatementEnd
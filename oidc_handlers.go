@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Kavantix/go-form/auth"
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/pkg/i18n"
+	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/Kavantix/go-form/templates"
+	"github.com/labstack/echo/v4"
+)
+
+type OidcAutoProvision bool
+
+func HandleOidcLogin(provider *auth.OidcProvider, isProduction bool) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		authUrl, state, err := provider.BeginLogin(c.Request().Context())
+		if err != nil {
+			return fmt.Errorf("failed to begin single sign-on: %w", err)
+		}
+		c.SetCookie(&http.Cookie{
+			Name:     "goform_oidc",
+			Value:    state,
+			Path:     "/login/oidc",
+			MaxAge:   10 * 60,
+			Secure:   isProduction,
+			HttpOnly: true,
+			// The cookie has to be sent along with the redirect back from the identity provider
+			SameSite: http.SameSiteLaxMode,
+		})
+		return c.Redirect(302, authUrl)
+	}
+}
+
+func HandleOidcCallback(provider *auth.OidcProvider, autoProvision OidcAutoProvision, isProduction bool, queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		cookie, err := c.Cookie("goform_oidc")
+		if err != nil {
+			return template(c, 400, templates.OidcLoginError(i18n.T(c.Request().Context(), "The login has expired, please try again.")))
+		}
+		c.SetCookie(&http.Cookie{
+			Name:     "goform_oidc",
+			Value:    "",
+			Path:     "/login/oidc",
+			MaxAge:   -1,
+			HttpOnly: true,
+		})
+		if errorCode := c.QueryParam("error"); errorCode != "" {
+			logger.EchoInfo(c, "Single sign-on was denied", slog.String("error", errorCode))
+			return template(c, 401, templates.OidcLoginError(i18n.T(c.Request().Context(), "The login was cancelled or denied.")))
+		}
+		identity, err := provider.FinishLogin(c.Request().Context(), cookie.Value, c.QueryParam("state"), c.QueryParam("code"))
+		if err != nil {
+			logger.EchoWarn(c, "Single sign-on failed", slog.String("reason", err.Error()))
+			return template(c, 401, templates.OidcLoginError(i18n.T(c.Request().Context(), "The login could not be verified, please try again.")))
+		}
+		user, err := queries.GetUserByEmail(c.Request().Context(), identity.Email)
+		if errors.Is(err, database.ErrNotFound) {
+			if !bool(autoProvision) || identity.BirthDate.IsZero() {
+				logger.EchoInfo(c, "Single sign-on for unknown user", slog.String("email", identity.Email))
+				return template(c, 403, templates.OidcLoginError(i18n.T(c.Request().Context(), "There is no account for your email address, ask an admin to create one.")))
+			}
+			name := identity.Name
+			if name == "" {
+				name, _, _ = strings.Cut(identity.Email, "@")
+			}
+			user.Id, err = queries.InsertUser(c.Request().Context(), name, identity.Email, identity.BirthDate)
+			if err != nil {
+				return fmt.Errorf("failed to provision user: %w", err)
+			}
+			logger.EchoInfo(c, "Provisioned user from single sign-on", slog.Int("user", int(user.Id)))
+		} else if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		} else if user.Status == database.UserStatusPending {
+			logger.EchoInfo(c, "Single sign-on for invited user", slog.Int("user", int(user.Id)))
+			return template(c, 403, templates.OidcLoginError(i18n.T(c.Request().Context(), "Accept the invitation that was mailed to you before logging in.")))
+		}
+		return startLoginSession(c, queries, user.Id, isProduction)
+	}
+}
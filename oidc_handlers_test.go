@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/Kavantix/go-form/auth"
+	"github.com/Kavantix/go-form/auth/oidctest"
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/pkg/baseurl"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/labstack/echo/v4"
+)
+
+// emptyDb is a database without any rows that fails every change
+type emptyDb struct{}
+
+type emptyRow struct{}
+
+func (emptyRow) Scan(dest ...any) error {
+	return pgx.ErrNoRows
+}
+
+func (emptyDb) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errors.New("the database is read only")
+}
+
+func (emptyDb) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, errors.New("the database cannot be queried")
+}
+
+func (emptyDb) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	return emptyRow{}
+}
+
+func TestOidcCallbackRejectsUnknownEmail(t *testing.T) {
+	err := baseurl.Init("http://go-form.test")
+	if err != nil {
+		t.Fatalf("failed to configure base url: %s", err)
+	}
+	dir := t.TempDir()
+	err = auth.AddKey(dir, "test", false)
+	if err != nil {
+		t.Fatalf("failed to add signing key: %s", err)
+	}
+	err = auth.LoadKeyring(dir, "test")
+	if err != nil {
+		t.Fatalf("failed to load signing key: %s", err)
+	}
+	mock := oidctest.NewProvider(t, "go-form")
+	provider := auth.NewOidcProvider(auth.OidcConfig{
+		Issuer:      mock.URL,
+		ClientId:    mock.ClientId,
+		RedirectUrl: "http://go-form.test/login/oidc/callback",
+	})
+	queries := database.New(emptyDb{})
+
+	// Without a birth date the user cannot be provisioned either
+	for _, autoProvision := range []OidcAutoProvision{false, true} {
+		r := echo.New()
+		r.GET("/login/oidc", HandleOidcLogin(provider, false))
+		r.GET("/login/oidc/callback", HandleOidcCallback(provider, autoProvision, false, queries))
+
+		response := httptest.NewRecorder()
+		r.ServeHTTP(response, httptest.NewRequest("GET", "/login/oidc", nil))
+		if response.Code != 302 {
+			t.Fatalf("login responded with %d, expected a redirect", response.Code)
+		}
+		authUrl := response.Header().Get("Location")
+		code := mock.Authorize(t, authUrl, map[string]any{
+			"email":          "unknown@example.com",
+			"email_verified": true,
+		})
+		parsed, err := url.Parse(authUrl)
+		if err != nil {
+			t.Fatalf("invalid auth url: %s", err)
+		}
+
+		callback := url.Values{"state": {parsed.Query().Get("state")}, "code": {code}}
+		request := httptest.NewRequest("GET", "/login/oidc/callback?"+callback.Encode(), nil)
+		for _, cookie := range response.Result().Cookies() {
+			request.AddCookie(cookie)
+		}
+		response = httptest.NewRecorder()
+		r.ServeHTTP(response, request)
+		if response.Code != http.StatusForbidden {
+			t.Errorf("callback with auto provision %v responded with %d, expected %d", autoProvision, response.Code, http.StatusForbidden)
+		}
+		for _, cookie := range response.Result().Cookies() {
+			if cookie.Name == "goform_auth" || cookie.Name == "goform_2fa" {
+				t.Errorf("callback with auto provision %v set cookie %s", autoProvision, cookie.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/pkg/i18n"
+	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/Kavantix/go-form/templates"
+	"github.com/Kavantix/go-form/templates/components"
+	"github.com/a-h/templ"
+	"github.com/labstack/echo/v4"
+)
+
+func renderAdminMails(c echo.Context, queries *database.Queries, toasts ...components.ToastConfig) error {
+	page, pageSize, err := paginationParams(c)
+	if err != nil {
+		return c.String(400, "invalid pagination")
+	}
+	messages, err := queries.GetOutboxPage(c.Request().Context(), page, pageSize)
+	if err != nil {
+		return fmt.Errorf("failed to get mails: %w", err)
+	}
+	templatesToRender := []templ.Component{
+		templates.AdminMails(messages),
+	}
+	for _, toast := range toasts {
+		templatesToRender = append(templatesToRender, components.Toast(toast))
+	}
+	return template(c, 200, templatesToRender...)
+}
+
+func HandleAdminMails(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return renderAdminMails(c, queries)
+	}
+}
+
+func HandleRetryMail(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.String(400, "invalid id")
+		}
+		retried, err := queries.RetryOutboxMessage(c.Request().Context(), int32(id))
+		if err != nil {
+			return fmt.Errorf("failed to retry mail: %w", err)
+		}
+		if retried == 0 {
+			return template(c, 404, templates.NotFound("/admin/mails"))
+		}
+		logger.EchoInfo(c, "Admin retried mail", slog.Int("message", id))
+		if !isHtmx(c) {
+			return c.Redirect(303, "/admin/mails")
+		}
+		return renderAdminMails(c, queries, components.ToastConfig{
+			Message: i18n.T(c.Request().Context(), "The mail will be sent again"),
+			Variant: components.ToastSuccess,
+		})
+	}
+}
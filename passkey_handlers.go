@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kavantix/go-form/auth"
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/pkg/i18n"
+	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/Kavantix/go-form/templates"
+	"github.com/Kavantix/go-form/templates/components"
+	"github.com/a-h/templ"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/labstack/echo/v4"
+)
+
+// maxPasskeyNameLength is the length of the name column of the passkeys table
+const maxPasskeyNameLength = 100
+
+// passkeyUser returns user together with the passkeys that are registered for it
+func passkeyUser(ctx context.Context, queries *database.Queries, user database.DisplayableUser) (auth.PasskeyUser, error) {
+	passkeys, err := queries.GetUserPasskeys(ctx, user.Id)
+	if err != nil {
+		return auth.PasskeyUser{}, fmt.Errorf("failed to get passkeys: %w", err)
+	}
+	credentials := []webauthn.Credential{}
+	for _, passkey := range passkeys {
+		credential := webauthn.Credential{}
+		err = json.Unmarshal(passkey.Credential, &credential)
+		if err != nil {
+			return auth.PasskeyUser{}, fmt.Errorf("invalid credential of passkey %d: %w", passkey.Id, err)
+		}
+		credentials = append(credentials, credential)
+	}
+	return auth.PasskeyUser{
+		Id:          user.Id,
+		Name:        user.Name,
+		Email:       user.Email,
+		Credentials: credentials,
+	}, nil
+}
+
+// passkeyCeremonyValidFor is how long the browser can take to finish a passkey ceremony
+const passkeyCeremonyValidFor = time.Minute * 5
+
+// setPasskeyCookie stores the state of a passkey ceremony until the browser finishes it,
+// the cookie only holds the id of the state so it cannot be used again once it was taken.
+func setPasskeyCookie(c echo.Context, queries *database.Queries, state string, isProduction bool) error {
+	id, err := auth.GenerateOTP(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate passkey ceremony id: %w", err)
+	}
+	// Ceremonies that were never finished are cleaned up before starting a new one
+	err = queries.DeleteExpiredPasskeyCeremonies(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to delete expired passkey ceremonies: %w", err)
+	}
+	err = queries.InsertPasskeyCeremony(c.Request().Context(), id, state, time.Now().Add(passkeyCeremonyValidFor))
+	if err != nil {
+		return fmt.Errorf("failed to save passkey ceremony: %w", err)
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     "goform_passkey",
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(passkeyCeremonyValidFor.Seconds()),
+		Secure:   isProduction,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return nil
+}
+
+// takePasskeyCookie returns the state of the current passkey ceremony and deletes it,
+// so a ceremony can only be finished once.
+func takePasskeyCookie(c echo.Context, queries *database.Queries, isProduction bool) (string, error) {
+	cookie, err := c.Cookie("goform_passkey")
+	if err != nil {
+		return "", err
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     "goform_passkey",
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   isProduction,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return queries.TakePasskeyCeremony(c.Request().Context(), cookie.Value)
+}
+
+func HandleBeginPasskeyLogin(isProduction bool, queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		options, state, err := auth.BeginPasskeyLogin()
+		if err != nil {
+			return fmt.Errorf("failed to begin passkey login: %w", err)
+		}
+		err = setPasskeyCookie(c, queries, state, isProduction)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, options)
+	}
+}
+
+// HandleFinishPasskeyLogin starts a session for the user of the passkey,
+// a passkey verifies the user so no second factor is asked.
+func HandleFinishPasskeyLogin(isProduction bool, queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		state, err := takePasskeyCookie(c, queries, isProduction)
+		if errors.Is(err, http.ErrNoCookie) || errors.Is(err, database.ErrNotFound) {
+			return c.String(400, i18n.T(c.Request().Context(), "Passkey login was not started"))
+		} else if err != nil {
+			return fmt.Errorf("failed to get passkey ceremony: %w", err)
+		}
+		user, credential, err := auth.FinishPasskeyLogin(state, c.Request(), func(userHandle []byte) (auth.PasskeyUser, error) {
+			userId, err := auth.PasskeyUserId(userHandle)
+			if err != nil {
+				return auth.PasskeyUser{}, err
+			}
+			user, err := queries.GetUser(c.Request().Context(), userId)
+			if err != nil {
+				return auth.PasskeyUser{}, err
+			}
+			return passkeyUser(c.Request().Context(), queries, user)
+		})
+		if errors.Is(err, auth.ErrPasskeyCloned) {
+			logger.EchoWarn(c, "Passkey login with a cloned passkey", slog.Int("user", int(user.Id)))
+			return c.String(401, i18n.T(c.Request().Context(), "This passkey cannot be used to log in"))
+		} else if err != nil {
+			logger.EchoInfo(c, "Passkey login failed", slog.String("reason", err.Error()))
+			return c.String(401, i18n.T(c.Request().Context(), "This passkey cannot be used to log in"))
+		}
+		// The sign count of the authenticator is saved so the next login can detect cloned passkeys
+		encodedCredential, err := json.Marshal(credential)
+		if err != nil {
+			return fmt.Errorf("failed to encode credential: %w", err)
+		}
+		err = queries.UpdatePasskeyCredential(c.Request().Context(), credential.ID, encodedCredential)
+		if err != nil {
+			return fmt.Errorf("failed to update passkey: %w", err)
+		}
+		err = setUserLoggedInCookie(c, queries, user.Id, isProduction)
+		if err != nil {
+			return fmt.Errorf("Failed to create token: %w", err)
+		}
+		return c.JSON(200, map[string]string{"redirect": "/users"})
+	}
+}
+
+func renderAccountPasskeys(c echo.Context, queries *database.Queries, toasts ...components.ToastConfig) error {
+	passkeys, err := queries.GetUserPasskeys(c.Request().Context(), authenticatedUserId(c))
+	if err != nil {
+		return fmt.Errorf("failed to get passkeys: %w", err)
+	}
+	templatesToRender := []templ.Component{
+		templates.AccountPasskeys(passkeys),
+	}
+	for _, toast := range toasts {
+		templatesToRender = append(templatesToRender, components.Toast(toast))
+	}
+	return template(c, 200, templatesToRender...)
+}
+
+func HandleAccountPasskeys(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return renderAccountPasskeys(c, queries)
+	}
+}
+
+func HandleBeginPasskeyRegistration(isProduction bool, queries *database.Queries, getUser GetUserFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUser(c)
+		if err != nil {
+			return err
+		}
+		passkeyUser, err := passkeyUser(c.Request().Context(), queries, *user)
+		if err != nil {
+			return err
+		}
+		options, state, err := auth.BeginPasskeyRegistration(passkeyUser)
+		if err != nil {
+			return fmt.Errorf("failed to begin passkey registration: %w", err)
+		}
+		err = setPasskeyCookie(c, queries, state, isProduction)
+		if err != nil {
+			return err
+		}
+		return c.JSON(200, options)
+	}
+}
+
+func HandleFinishPasskeyRegistration(isProduction bool, queries *database.Queries, getUser GetUserFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUser(c)
+		if err != nil {
+			return err
+		}
+		state, err := takePasskeyCookie(c, queries, isProduction)
+		if errors.Is(err, http.ErrNoCookie) || errors.Is(err, database.ErrNotFound) {
+			return c.String(400, i18n.T(c.Request().Context(), "Passkey registration was not started"))
+		} else if err != nil {
+			return fmt.Errorf("failed to get passkey ceremony: %w", err)
+		}
+		passkeyUser, err := passkeyUser(c.Request().Context(), queries, *user)
+		if err != nil {
+			return err
+		}
+		credential, err := auth.FinishPasskeyRegistration(passkeyUser, state, c.Request())
+		if err != nil {
+			logger.EchoInfo(c, "Passkey registration failed", slog.String("reason", err.Error()))
+			return c.String(400, i18n.T(c.Request().Context(), "The passkey could not be registered"))
+		}
+		name := strings.TrimSpace(strings.ToValidUTF8(c.QueryParam("name"), ""))
+		if name == "" {
+			name = "Passkey"
+		}
+		// The name is truncated by characters, cutting a multi-byte character in half makes it invalid
+		if runes := []rune(name); len(runes) > maxPasskeyNameLength {
+			name = string(runes[:maxPasskeyNameLength])
+		}
+		encodedCredential, err := json.Marshal(credential)
+		if err != nil {
+			return fmt.Errorf("failed to encode credential: %w", err)
+		}
+		_, err = queries.InsertPasskey(c.Request().Context(), database.InsertPasskeyParams{
+			UserID:       user.Id,
+			Name:         name,
+			CredentialID: credential.ID,
+			Credential:   encodedCredential,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save passkey: %w", err)
+		}
+		logger.EchoInfo(c, "Registered passkey", slog.Int("user", int(user.Id)))
+		return c.JSON(200, map[string]string{"redirect": "/account/passkeys"})
+	}
+}
+
+func HandleDeletePasskey(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.String(400, "invalid id")
+		}
+		deleted, err := queries.DeleteUserPasskey(c.Request().Context(), int32(id), authenticatedUserId(c))
+		if err != nil {
+			return fmt.Errorf("failed to delete passkey: %w", err)
+		}
+		if deleted == 0 {
+			return template(c, 404, templates.NotFound("/account/passkeys"))
+		}
+		logger.EchoInfo(c, "Deleted passkey", slog.Int("passkey", id))
+		if !isHtmx(c) {
+			return c.Redirect(303, "/account/passkeys")
+		}
+		return renderAccountPasskeys(c, queries, components.ToastConfig{
+			Message: i18n.T(c.Request().Context(), "Passkey deleted"),
+			Variant: components.ToastSuccess,
+		})
+	}
+}
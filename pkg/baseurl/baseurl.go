@@ -0,0 +1,58 @@
+// Package baseurl builds the absolute urls of the site from the configured public base url,
+// so they do not depend on the host header of a request.
+package baseurl
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var base *url.URL
+
+// Init sets the url the site is publicly served from, like `https://go-form.example.com`
+func Init(rawUrl string) error {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return fmt.Errorf("invalid base url: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("base url `%s` must start with http:// or https://", rawUrl)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("base url `%s` has no host", rawUrl)
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return errors.New("base url cannot have a query or fragment")
+	}
+	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+	parsed.RawPath = ""
+	base = parsed
+	return nil
+}
+
+// Url returns the absolute url of path with query, path is relative to the base url
+func Url(path string, query url.Values) string {
+	result := *mustBase()
+	result.Path = base.Path + "/" + strings.TrimPrefix(path, "/")
+	result.RawQuery = query.Encode()
+	return result.String()
+}
+
+// Origin returns the scheme and host of the base url, like `https://go-form.example.com`
+func Origin() string {
+	return fmt.Sprintf("%s://%s", mustBase().Scheme, base.Host)
+}
+
+// Hostname returns the host of the base url without its port
+func Hostname() string {
+	return mustBase().Hostname()
+}
+
+func mustBase() *url.URL {
+	if base == nil {
+		panic("baseurl is used before Init")
+	}
+	return base
+}
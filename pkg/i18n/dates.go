@@ -0,0 +1,32 @@
+package i18n
+
+import (
+	"fmt"
+	"time"
+)
+
+var monthNames = map[string][12]string{
+	Dutch: {"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+}
+
+var shortMonthNames = map[string][12]string{
+	Dutch: {"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+}
+
+// FormatDate formats t like `14 March 1990` in locale
+func FormatDate(locale string, t time.Time) string {
+	months, ok := monthNames[locale]
+	if !ok {
+		return t.Format("2 January 2006")
+	}
+	return fmt.Sprintf("%d %s %d", t.Day(), months[t.Month()-1], t.Year())
+}
+
+// FormatDateTime formats t like `14 Mar 1990 15:04` in locale
+func FormatDateTime(locale string, t time.Time) string {
+	months, ok := shortMonthNames[locale]
+	if !ok {
+		return t.Format("2 Jan 2006 15:04")
+	}
+	return fmt.Sprintf("%d %s %d %s", t.Day(), months[t.Month()-1], t.Year(), t.Format("15:04"))
+}
@@ -0,0 +1,89 @@
+// Package i18n translates the ui and mails.
+// Messages are keyed by their English text, so untranslated messages are shown in English.
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	English = "en"
+	Dutch   = "nl"
+)
+
+// Default is the locale used when no supported locale is requested
+const Default = English
+
+// Locales are the supported locales, in the order they are offered
+var Locales = []string{English, Dutch}
+
+// catalogs are the translations of every locale except English, keyed by the English message
+var catalogs = map[string]map[string]string{
+	Dutch: dutch,
+}
+
+var names = map[string]string{
+	English: "English",
+	Dutch:   "Nederlands",
+}
+
+var matcher = language.NewMatcher([]language.Tag{language.English, language.Dutch})
+
+// Supported reports whether locale is one of Locales
+func Supported(locale string) bool {
+	return slices.Contains(Locales, locale)
+}
+
+// Name returns the name of locale in its own language
+func Name(locale string) string {
+	return names[locale]
+}
+
+// Negotiate returns the supported locale that matches an Accept-Language header best
+func Negotiate(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+	return Locales[index]
+}
+
+type contextKey struct{}
+
+// WithLocale returns a copy of ctx in which messages are translated to locale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// FromContext returns the locale of ctx, or Default when it has none
+func FromContext(ctx context.Context) string {
+	locale, ok := ctx.Value(contextKey{}).(string)
+	if !ok {
+		return Default
+	}
+	return locale
+}
+
+// T translates message to the locale of ctx, see Tr
+func T(ctx context.Context, message string, args ...any) string {
+	return Tr(FromContext(ctx), message, args...)
+}
+
+// Tr translates message to locale, when args are given the translation is formatted with them like fmt.Sprintf
+func Tr(locale, message string, args ...any) string {
+	if translation, ok := catalogs[locale][message]; ok {
+		message = translation
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
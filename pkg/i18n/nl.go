@@ -0,0 +1,250 @@
+package i18n
+
+// dutch are the Dutch translations, keyed by the English message
+var dutch = map[string]string{
+	"%d days":                        "%d dagen",
+	"%d minutes":                     "%d minuten",
+	"%d years":                       "%d jaar",
+	"%s has invited you to go-form.": "%s heeft je uitgenodigd voor go-form.",
+	"%s on %s":                       "%s op %s",
+	"Accept invitation":              "Uitnodiging accepteren",
+	"Accept the invitation that was mailed to you before logging in.": "Accepteer eerst de uitnodiging die je gemaild is voordat je inlogt.",
+	"Account":           "Account",
+	"Active":            "Actief",
+	"Add Assignments":   "Opdrachten toevoegen",
+	"Add Users":         "Gebruikers toevoegen",
+	"Add answer option": "Antwoordoptie toevoegen",
+	"Add passkey":       "Passkey toevoegen",
+	"Added":             "Toegevoegd",
+	"Adding the passkey was cancelled or failed": "Het toevoegen van de passkey is geannuleerd of mislukt",
+	"Admin":             "Beheerder",
+	"Age":               "Leeftijd",
+	"Answer":            "Antwoord",
+	"Answer options":    "Antwoordopties",
+	"Answers":           "Antwoorden",
+	"Api token revoked": "Api-token ingetrokken",
+	"Api tokens":        "Api-tokens",
+	"Api tokens let scripts access your account, send them in the Authorization: Bearer header.": "Met api-tokens hebben scripts toegang tot je account, stuur ze mee in de Authorization: Bearer header.",
+	"Assignments":                                  "Opdrachten",
+	"At least %d items are required":               "Er zijn minimaal %d items nodig",
+	"At least one answer option should be correct": "Minimaal één antwoordoptie moet juist zijn",
+	"At most %d items are allowed":                 "Er zijn maximaal %d items toegestaan",
+	"Attempts":                                     "Pogingen",
+	"Back":                                         "Vorige",
+	"Back to login":                                "Terug naar inloggen",
+	"Birthdate":                                    "Geboortedatum",
+	"Cancel":                                       "Annuleren",
+	"Check your mailbox for a login link.":         "Kijk in je mailbox voor een inloglink.",
+	"Choose a type":                                "Kies een type",
+	"Code":                                         "Code",
+	"Confirm and log in":                           "Bevestigen en inloggen",
+	"Copy your new api token now, it will not be shown again.": "Kopieer je nieuwe api-token nu, het wordt niet nog een keer getoond.",
+	"Copyright © %v Pieter van Loon. All rights reserved.":     "Copyright © %v Pieter van Loon. Alle rechten voorbehouden.",
+	"Correct":                               "Juist",
+	"Create new %s":                         "Nieuwe %s aanmaken",
+	"Create token":                          "Token aanmaken",
+	"Created":                               "Aangemaakt",
+	"Date of birth":                         "Geboortedatum",
+	"Date of birth cannot be in the future": "De geboortedatum kan niet in de toekomst liggen",
+	"Delete":                                "Verwijderen",
+	"Delete passkey %s?":                    "Passkey %s verwijderen?",
+	"Deleted %d mails":                      "%d mails verwijderd",
+	"Details":                               "Details",
+	"Device":                                "Apparaat",
+	"Disable":                               "Uitschakelen",
+	"Discard":                               "Verwijderen",
+	"Do you want to renew your session?":    "Wil je je sessie verlengen?",
+	"Down":                                  "Omlaag",
+	"Email":                                 "E-mail",
+	"Email already used":                    "E-mailadres is al in gebruik",
+	"Enable":                                "Inschakelen",
+	"Enter a code to disable two-factor authentication": "Voer een code in om tweestapsverificatie uit te schakelen",
+	"Enter a code to generate new recovery codes":       "Voer een code in om nieuwe herstelcodes te genereren",
+	"Enter a name":                "Voer een naam in",
+	"Enter a valid date":          "Voer een geldige datum in",
+	"Enter a valid email address": "Voer een geldig e-mailadres in",
+	"Enter an answer":             "Voer een antwoord in",
+	"Enter an email":              "Voer een e-mailadres in",
+	"Enter the code from your authenticator app or one of your recovery codes.": "Voer de code uit je authenticator-app of een van je herstelcodes in.",
+	"Enter the code shown by the app":                                           "Voer de code in die de app toont",
+	"Enter the date of birth":                                                   "Voer de geboortedatum in",
+	"Enter this token in the validation field:":                                 "Voer dit token in het validatieveld in:",
+	"Expired":                     "Verlopen",
+	"Expires":                     "Verloopt",
+	"Expires after":               "Verloopt na",
+	"Generate new recovery codes": "Nieuwe herstelcodes genereren",
+	"Go back":                     "Ga terug",
+	"Hi":                          "Hoi",
+	"IP address":                  "IP-adres",
+	"Id":                          "Id",
+	"If the email is known a login link will be generated.":                                                    "Als het e-mailadres bekend is wordt er een inloglink aangemaakt.",
+	"If you did not expect this change, please contact an admin.":                                              "Had je deze wijziging niet verwacht, neem dan contact op met een beheerder.",
+	"If you’re having trouble with the button '{ACTION}', copy and paste the URL below into your web browser.": "Werkt de knop '{ACTION}' niet, kopieer dan de onderstaande URL en plak die in je webbrowser.",
+	"Impersonate":             "Inloggen als",
+	"Invalid number of items": "Ongeldig aantal items",
+	"Invalid value":           "Ongeldige waarde",
+	"Invitation revoked":      "Uitnodiging ingetrokken",
+	"Invitation sent to %s":   "Uitnodiging verstuurd naar %s",
+	"Invitations":             "Uitnodigingen",
+	"Invitations can only be used once and expire after a week, ask an admin to send a new one.": "Uitnodigingen kunnen maar één keer gebruikt worden en verlopen na een week, vraag een beheerder om een nieuwe te sturen.",
+	"Invited":    "Uitgenodigd",
+	"Invited by": "Uitgenodigd door",
+	"Invited users receive a link by mail to confirm their details and log in for the first time.": "Uitgenodigde gebruikers krijgen een link per mail om hun gegevens te bevestigen en voor het eerst in te loggen.",
+	"Language":                "Taal",
+	"Language of the browser": "Taal van de browser",
+	"Last error":              "Laatste fout",
+	"Last seen":               "Laatst gezien",
+	"Last used":               "Laatst gebruikt",
+	"Link sent":               "Link verstuurd",
+	"Log in":                  "Inloggen",
+	"Log in to go-form":       "Inloggen bij go-form",
+	"Log in with %s":          "Inloggen met %s",
+	"Log in with a passkey":   "Inloggen met een passkey",
+	"Logging in with a passkey was cancelled or failed": "Inloggen met een passkey is geannuleerd of mislukt",
+	"Login": "Inloggen",
+	"Login links can only be used once and expire after a few minutes.": "Inloglinks kunnen maar één keer gebruikt worden en verlopen na een paar minuten.",
+	"Logout": "Uitloggen",
+	"Mails":  "Mails",
+	"Mails are queued and delivered in the background, failed deliveries are retried with increasing delays.": "Mails worden in een wachtrij gezet en op de achtergrond bezorgd, mislukte bezorgingen worden met toenemende tussenpozen opnieuw geprobeerd.",
+	"Mails are sent to you in this language as well.":                                                         "Mails worden ook in deze taal naar je verstuurd.",
+	"Minimum age is 18":                 "De minimumleeftijd is 18",
+	"My sessions":                       "Mijn sessies",
+	"Name":                              "Naam",
+	"Name can be at most %d characters": "De naam mag maximaal %d tekens lang zijn",
+	"Name is required":                  "Naam is verplicht",
+	"Name of the passkey":               "Naam van de passkey",
+	"Need help, or have questions? Just reply to this email, we'd love to help.": "Hulp nodig of vragen? Beantwoord deze e-mail, we helpen je graag.",
+	"Never":                                "Nooit",
+	"New assignment: {{.Row.Name}}":        "Nieuwe opdracht: {{.Row.Name}}",
+	"Next":                                 "Volgende",
+	"No":                                   "Nee",
+	"Not all fields are valid":             "Niet alle velden zijn geldig",
+	"Not allowed":                          "Niet toegestaan",
+	"Not found":                            "Niet gevonden",
+	"Order":                                "Volgorde",
+	"Passkey deleted":                      "Passkey verwijderd",
+	"Passkey login was not started":        "Inloggen met een passkey is niet gestart",
+	"Passkey registration was not started": "Het registreren van een passkey is niet gestart",
+	"Passkeys":                             "Passkeys",
+	"Passkeys let you log in with your fingerprint, face or device pin instead of a login link.": "Met passkeys log je in met je vingerafdruk, gezicht of de pincode van je apparaat in plaats van een inloglink.",
+	"Please confirm your details to finish setting up your account for %s.":                      "Bevestig je gegevens om je account voor %s af te ronden.",
+	"Please enter the token you received at %s":                                                  "Voer het token in dat je op %s hebt ontvangen",
+	"Please try again later or refresh the page.":                                                "Probeer het later opnieuw of ververs de pagina.",
+	"Please wait %s before requesting a new login link.":                                         "Wacht %s voordat je een nieuwe inloglink aanvraagt.",
+	"Preferences":       "Voorkeuren",
+	"Preferences saved": "Voorkeuren opgeslagen",
+	"Protect your account with a code from an authenticator app when logging in.": "Beveilig je account met een code uit een authenticator-app bij het inloggen.",
+	"Qr code of the two-factor secret":                                            "Qr-code van het tweestapsgeheim",
+	"Queued":                                                                      "In de wachtrij",
+	"Remove":                                                                      "Verwijderen",
+	"Request a new login link":                                                    "Vraag een nieuwe inloglink aan",
+	"Request login link":                                                          "Inloglink aanvragen",
+	"Require two-factor authentication":                                           "Tweestapsverificatie verplichten",
+	"Require two-factor authentication for %s":                                    "Tweestapsverificatie verplichten voor %s",
+	"Resend":             "Opnieuw versturen",
+	"Restore draft":      "Concept herstellen",
+	"Retry":              "Opnieuw proberen",
+	"Revoke":             "Intrekken",
+	"Revoke all of user": "Alles van gebruiker intrekken",
+	"Revoke the invitation of %s? Their account will be removed.": "De uitnodiging van %s intrekken? Het account wordt verwijderd.",
+	"Revoked %d sessions": "%d sessies ingetrokken",
+	"Role":                "Rol",
+	"Roles":               "Rollen",
+	"Roles saved":         "Rollen opgeslagen",
+	"Save":                "Opslaan",
+	"Scan the qr code with your authenticator app, or enter the secret manually.": "Scan de qr-code met je authenticator-app, of voer het geheim handmatig in.",
+	"Scopes":                                 "Scopes",
+	"Select at least one scope":              "Selecteer minimaal één scope",
+	"Send a new token":                       "Stuur een nieuw token",
+	"Send invitation":                        "Uitnodiging versturen",
+	"Sent":                                   "Verstuurd",
+	"Session revoked":                        "Sessie ingetrokken",
+	"Sessions":                               "Sessies",
+	"Set up two-factor authentication":       "Tweestapsverificatie instellen",
+	"Sign out everywhere":                    "Overal uitloggen",
+	"Signed in":                              "Ingelogd",
+	"Something went wrong":                   "Er ging iets mis",
+	"Something went wrong, please try again": "Er ging iets mis, probeer het opnieuw",
+	"Sound":                                  "Geluid",
+	"Sound type is not supported yet":        "Het type geluid wordt nog niet ondersteund",
+	"Status":                                 "Status",
+	"Stop impersonating":                     "Stoppen met inloggen als",
+	"Store these recovery codes somewhere safe, each can be used once when you cannot use your authenticator app. They will not be shown again.": "Bewaar deze herstelcodes op een veilige plek, elke code kan één keer gebruikt worden als je je authenticator-app niet kunt gebruiken. Ze worden niet nog een keer getoond.",
+	"Subject":                                "Onderwerp",
+	"Successfully created %s":                "%s succesvol aangemaakt",
+	"Successfully updated %s":                "%s succesvol bijgewerkt",
+	"Text":                                   "Tekst",
+	"The code is invalid, please try again.": "De code is ongeldig, probeer het opnieuw.",
+	"The invitation to %s could not be sent, please resend it":                "De uitnodiging aan %s kon niet worden verstuurd, verstuur hem opnieuw",
+	"The login could not be verified, please try again.":                      "De login kon niet worden geverifieerd, probeer het opnieuw.",
+	"The login has expired, please try again.":                                "De login is verlopen, probeer het opnieuw.",
+	"The login was cancelled or denied.":                                      "De login is geannuleerd of geweigerd.",
+	"The mail will be sent again":                                             "De mail wordt opnieuw verstuurd",
+	"The passkey could not be registered":                                     "De passkey kon niet worden geregistreerd",
+	"There is no account for your email address, ask an admin to create one.": "Er is geen account voor je e-mailadres, vraag een beheerder om er een aan te maken.",
+	"This cannot be done while impersonating a user.":                         "Dit kan niet terwijl je bent ingelogd als een andere gebruiker.",
+	"This device":                           "Dit apparaat",
+	"This field is required":                "Dit veld is verplicht",
+	"This invitation expires on %s.":        "Deze uitnodiging verloopt op %s.",
+	"This invitation has expired.":          "Deze uitnodiging is verlopen.",
+	"This invitation is invalid.":           "Deze uitnodiging is ongeldig.",
+	"This invitation is no longer valid.":   "Deze uitnodiging is niet meer geldig.",
+	"This invitation was already accepted.": "Deze uitnodiging is al geaccepteerd.",
+	"This login link has expired.":          "Deze inloglink is verlopen.",
+	"This login link is invalid.":           "Deze inloglink is ongeldig.",
+	"This login link was already used.":     "Deze inloglink is al gebruikt.",
+	"This page has expired":                 "Deze pagina is verlopen",
+	"This passkey cannot be used to log in": "Met deze passkey kan niet worden ingelogd",
+	"To":                                    "Aan",
+	"To accept the invitation and set up your account, please click here:": "Klik hier om de uitnodiging te accepteren en je account in te stellen:",
+	"To get started with go-form, please click here:":                      "Klik hier om met go-form aan de slag te gaan:",
+	"Token":                            "Token",
+	"Token `%s` is invalid or expired": "Token `%s` is ongeldig of verlopen",
+	"Too many attempts, please try again in %s.":    "Te veel pogingen, probeer het over %s opnieuw.",
+	"Too many login attempts":                       "Te veel inlogpogingen",
+	"Too short, minimum length is 10":               "Te kort, de minimale lengte is 10",
+	"Two-factor authentication":                     "Tweestapsverificatie",
+	"Two-factor authentication disabled":            "Tweestapsverificatie uitgeschakeld",
+	"Two-factor authentication enabled":             "Tweestapsverificatie ingeschakeld",
+	"Two-factor authentication is already enabled.": "Tweestapsverificatie is al ingeschakeld.",
+	"Two-factor authentication is enabled.":         "Tweestapsverificatie is ingeschakeld.",
+	"Type":                                          "Type",
+	"Unknown browser":                               "Onbekende browser",
+	"Unknown role":                                  "Onbekende rol",
+	"Unknown scope %s":                              "Onbekende scope %s",
+	"Up":                                            "Omhoog",
+	"User":                                          "Gebruiker",
+	"Users":                                         "Gebruikers",
+	"Validate":                                      "Valideren",
+	"View assignment":                               "Opdracht bekijken",
+	"We could not find what you are looking for.":                  "We konden niet vinden wat je zoekt.",
+	"Welcome to go-form":                                           "Welkom bij go-form",
+	"Welcome to go-form! We're very excited to have you on board.": "Welkom bij go-form! We zijn erg blij dat je erbij bent.",
+	"Yes":                                    "Ja",
+	"You are impersonating %s":               "Je bent ingelogd als %s",
+	"You are invited to go-form":             "Je bent uitgenodigd voor go-form",
+	"You have %d unused recovery codes.":     "Je hebt %d ongebruikte herstelcodes.",
+	"You have an unsaved draft from":         "Je hebt een niet opgeslagen concept van",
+	"You have been invited to go-form.":      "Je bent uitgenodigd voor go-form.",
+	"Your account was updated":               "Je account is bijgewerkt",
+	"Your browser does not support passkeys": "Je browser ondersteunt geen passkeys",
+	"Your go-form login token: %s":           "Je go-form inlogtoken: %s",
+	"Your login link to go-form":             "Je inloglink voor go-form",
+	"Your request could not be verified, please reload the page and try again.": "Je verzoek kon niet worden geverifieerd, herlaad de pagina en probeer het opnieuw.",
+	"Your role requires two-factor authentication, set it up to continue.":      "Je rol vereist tweestapsverificatie, stel het in om verder te gaan.",
+	"Your role requires two-factor authentication.":                             "Je rol vereist tweestapsverificatie.",
+	"Your session has expired":                                                  "Je sessie is verlopen",
+	"Yours truly":                                                               "Met vriendelijke groet",
+	"`%s` is not a valid option":                                                "`%s` is geen geldige optie",
+	"a minute":                                                                  "een minuut",
+	"admin":                                                                     "beheerder",
+	"failed":                                                                    "mislukt",
+	"impersonated":                                                              "ingelogd als",
+	"pending":                                                                   "in de wachtrij",
+	"sent":                                                                      "verstuurd",
+	"sound":                                                                     "geluid",
+	"text":                                                                      "tekst",
+	"user":                                                                      "gebruiker",
+	"{{.Actor}} created the {{t .Row.Type}} assignment {{.Row.Name}}.":                                           "{{.Actor}} heeft de {{t .Row.Type}}opdracht {{.Row.Name}} aangemaakt.",
+	"{{.Actor}} updated the details of your account, your date of birth is now {{formatDate .Row.DateOfBirth}}.": "{{.Actor}} heeft de gegevens van je account bijgewerkt, je geboortedatum is nu {{formatDate .Row.DateOfBirth}}.",
+}
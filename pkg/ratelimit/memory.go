@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often windows that ended are removed from a MemoryStore
+const sweepInterval = time.Minute
+
+type memoryWindow struct {
+	hits    int
+	resetAt time.Time
+}
+
+// MemoryStore keeps hits in memory, it is not shared between instances of the server
+type MemoryStore struct {
+	mutex     sync.Mutex
+	windows   map[string]*memoryWindow
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		windows:   map[string]*memoryWindow{},
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Hit(ctx context.Context, key string, window time.Duration) (int, time.Time, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) > sweepInterval {
+		for windowKey, w := range s.windows {
+			if !now.Before(w.resetAt) {
+				delete(s.windows, windowKey)
+			}
+		}
+		s.lastSweep = now
+	}
+	w, ok := s.windows[key]
+	if !ok || !now.Before(w.resetAt) {
+		w = &memoryWindow{resetAt: now.Add(window)}
+		s.windows[key] = w
+	}
+	w.hits++
+	return w.hits, w.resetAt, nil
+}
@@ -0,0 +1,44 @@
+// Package ratelimit counts hits per key in fixed windows to throttle abuse.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Store keeps the number of hits per key
+type Store interface {
+	// Hit counts a hit for key and returns the hits in the current window including this one,
+	// a new window of length window starts when the previous one has ended.
+	Hit(ctx context.Context, key string, window time.Duration) (hits int, resetAt time.Time, err error)
+}
+
+// Limit allows Max hits per Window
+type Limit struct {
+	// Name is used to keep the hits of different limits apart
+	Name   string
+	Max    int
+	Window time.Duration
+}
+
+type Limiter struct {
+	store Store
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store}
+}
+
+// Allow counts a hit of key against limit,
+// when the limit is exceeded retryAfter is how long until the next hit is allowed.
+func (l *Limiter) Allow(ctx context.Context, limit Limit, key string) (allowed bool, retryAfter time.Duration, err error) {
+	hits, resetAt, err := l.store.Hit(ctx, fmt.Sprintf("%s:%s", limit.Name, key), limit.Window)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to count hit for %s: %w", limit.Name, err)
+	}
+	if hits > limit.Max {
+		return false, time.Until(resetAt), nil
+	}
+	return true, 0, nil
+}
@@ -0,0 +1,84 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Kavantix/go-form/pkg/ratelimit"
+	"github.com/Kavantix/go-form/pkg/ratelimit/ratelimittest"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ratelimittest.TestStore(t, ratelimit.NewMemoryStore())
+}
+
+func TestLimiterAllowsMaxHits(t *testing.T) {
+	ctx := context.Background()
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore())
+	limit := ratelimit.Limit{Name: "test", Max: 3, Window: time.Minute}
+
+	for i := 1; i <= limit.Max; i++ {
+		allowed, retryAfter, err := limiter.Allow(ctx, limit, "key")
+		if err != nil {
+			t.Fatalf("failed to allow: %s", err)
+		}
+		if !allowed || retryAfter != 0 {
+			t.Fatalf("hit %d was not allowed, retry after %s", i, retryAfter)
+		}
+	}
+	allowed, retryAfter, err := limiter.Allow(ctx, limit, "key")
+	if err != nil {
+		t.Fatalf("failed to allow: %s", err)
+	}
+	if allowed {
+		t.Fatalf("hit %d was allowed", limit.Max+1)
+	}
+	if retryAfter <= 0 || retryAfter > limit.Window {
+		t.Errorf("retry after %s, expected a duration within the window of %s", retryAfter, limit.Window)
+	}
+}
+
+func TestLimiterKeepsKeysAndLimitsApart(t *testing.T) {
+	ctx := context.Background()
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore())
+	limit := ratelimit.Limit{Name: "test", Max: 1, Window: time.Minute}
+	otherLimit := ratelimit.Limit{Name: "other", Max: 1, Window: time.Minute}
+
+	allowed, _, _ := limiter.Allow(ctx, limit, "key")
+	if !allowed {
+		t.Fatal("first hit was not allowed")
+	}
+	allowed, _, _ = limiter.Allow(ctx, limit, "other-key")
+	if !allowed {
+		t.Error("hit of another key was not allowed")
+	}
+	allowed, _, _ = limiter.Allow(ctx, otherLimit, "key")
+	if !allowed {
+		t.Error("hit of the same key for another limit was not allowed")
+	}
+	allowed, _, _ = limiter.Allow(ctx, limit, "key")
+	if allowed {
+		t.Error("second hit was allowed")
+	}
+}
+
+func TestLimiterAllowsHitsAgainAfterWindow(t *testing.T) {
+	ctx := context.Background()
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore())
+	limit := ratelimit.Limit{Name: "test", Max: 1, Window: time.Millisecond * 100}
+
+	limiter.Allow(ctx, limit, "key")
+	allowed, retryAfter, _ := limiter.Allow(ctx, limit, "key")
+	if allowed {
+		t.Fatal("second hit was allowed")
+	}
+	time.Sleep(retryAfter + time.Millisecond*10)
+	allowed, _, err := limiter.Allow(ctx, limit, "key")
+	if err != nil {
+		t.Fatalf("failed to allow: %s", err)
+	}
+	if !allowed {
+		t.Error("hit after retry after was not allowed")
+	}
+}
@@ -0,0 +1,69 @@
+// Package ratelimittest tests implementations of ratelimit.Store.
+package ratelimittest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Kavantix/go-form/pkg/ratelimit"
+)
+
+// window is short so the test can wait for it to end
+const window = time.Millisecond * 200
+
+// TestStore checks that store counts the hits per key in fixed windows,
+// the keys it uses start with the name of the test.
+func TestStore(t *testing.T, store ratelimit.Store) {
+	t.Helper()
+	ctx := context.Background()
+	key := t.Name() + ":key"
+	otherKey := t.Name() + ":other"
+
+	before := time.Now()
+	hits, resetAt, err := store.Hit(ctx, key, window)
+	after := time.Now()
+	if err != nil {
+		t.Fatalf("failed to hit: %s", err)
+	}
+	if hits != 1 {
+		t.Fatalf("first hit counted %d hits, expected 1", hits)
+	}
+	// Stores may round the time to microseconds
+	if resetAt.Before(before.Add(window-time.Millisecond)) || resetAt.After(after.Add(window+time.Millisecond)) {
+		t.Fatalf("window resets at %s, expected %s after the hit", resetAt, window)
+	}
+
+	for expected := 2; expected <= 3; expected++ {
+		hits, nextResetAt, err := store.Hit(ctx, key, window)
+		if err != nil {
+			t.Fatalf("failed to hit: %s", err)
+		}
+		if hits != expected {
+			t.Errorf("counted %d hits, expected %d", hits, expected)
+		}
+		if !nextResetAt.Equal(resetAt) {
+			t.Errorf("window moved from %s to %s", resetAt, nextResetAt)
+		}
+	}
+
+	hits, _, err = store.Hit(ctx, otherKey, window)
+	if err != nil {
+		t.Fatalf("failed to hit: %s", err)
+	}
+	if hits != 1 {
+		t.Errorf("other key counted %d hits, expected 1", hits)
+	}
+
+	time.Sleep(time.Until(resetAt) + time.Millisecond*10)
+	hits, nextResetAt, err := store.Hit(ctx, key, window)
+	if err != nil {
+		t.Fatalf("failed to hit: %s", err)
+	}
+	if hits != 1 {
+		t.Errorf("hit after the window ended counted %d hits, expected 1", hits)
+	}
+	if !nextResetAt.After(resetAt) {
+		t.Errorf("new window resets at %s, which is not after the previous window at %s", nextResetAt, resetAt)
+	}
+}
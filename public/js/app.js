@@ -1,15 +1,168 @@
+/** @returns {Record<string, string>} the headers that state-changing requests need */
+function csrfHeaders() {
+  const token = document.querySelector('meta[name="csrf-token"]')?.content ?? "";
+  return { "X-CSRF-Token": token };
+}
+
+/**
+ * Translates a message to the language of the page, see scriptMessages in templates/functions.go
+ * @param {string} message the English message
+ */
+function t(message) {
+  const translations = JSON.parse(document.querySelector('meta[name="i18n-messages"]')?.content ?? "{}");
+  return translations[message] ?? message;
+}
+
+document.addEventListener("htmx:configRequest", (evt) => {
+  Object.assign(evt.detail.headers, csrfHeaders());
+});
+
+/**
+ * Flattens the form fields into params,
+ * items of repeater fields are added with indexed names like `name[0].subField`.
+ * Unchecked checkboxes are left out like in a regular form submission.
+ * @param {Record<string, string | Record<string, string | boolean>[]>} fields
+ */
+function formParams(fields) {
+  const params = new URLSearchParams();
+  for (const [name, value] of Object.entries(fields)) {
+    if (!Array.isArray(value)) {
+      params.append(name, value);
+      continue;
+    }
+    value.forEach((item, index) => {
+      for (const [subField, subValue] of Object.entries(item)) {
+        if (subValue === false) continue;
+        params.append(
+          `${name}[${index}].${subField}`,
+          subValue === true ? "true" : subValue,
+        );
+      }
+    });
+  }
+  return params;
+}
+
 /**
  * @param {string} url
  * @param {{
- *   fields: Record<string, string>
+ *   fields: Record<string, string | Record<string, string | boolean>[]>
  *   validationErrors: Record<string, string>
  * }} data
  */
 async function validateForm(url, data) {
-  url = `${url}?` + new URLSearchParams(data.fields);
+  const params = formParams(data.fields);
+  if (data.step != undefined) {
+    // only validate the fields up to the current step of a multi-step form
+    params.set("step", data.step);
+  }
+  url = `${url}?` + params;
+  const response = await fetch(url);
+  if (response.status === 429) {
+    // Validation is throttled, the current errors are kept
+    return;
+  }
   /** @type {{validationErrors: Record<string, string> | undefined}} */
-  const response = await fetch(url).then((r) => r.json());
-  data.validationErrors = response.validationErrors ?? {};
+  const body = await response.json();
+  data.validationErrors = body.validationErrors ?? {};
+}
+
+/**
+ * Moves a multi-step form to the next step when the current step is valid
+ * @param {string} url
+ * @param {{
+ *   fields: Record<string, string | Record<string, string | boolean>[]>
+ *   validationErrors: Record<string, string>
+ *   step: number
+ *   stepCount: number
+ * }} data
+ */
+async function nextStep(url, data) {
+  await validateForm(url, data);
+  if (Object.keys(data.validationErrors).length === 0) {
+    data.step = Math.min(data.step + 1, data.stepCount - 1);
+  }
+}
+
+/** Interval in milliseconds at which changes to a form are saved as draft */
+const draftIntervalMs = 10_000;
+
+/**
+ * Offers to restore the draft of a form when there is one
+ * and saves changes to the fields as draft periodically until the form is removed.
+ * @param {string} url
+ * @param {{
+ *   fields: Record<string, string | Record<string, string | boolean>[]>
+ *   draft: {fields: Record<string, any>, updatedAt: string} | null
+ * }} data
+ * @param {HTMLFormElement} form
+ */
+async function autosaveDraft(url, data, form) {
+  // Wait for the fields to be initialized, repeaters convert their checkboxes to booleans
+  await Alpine.nextTick();
+  let savedFields = JSON.stringify(data.fields);
+  try {
+    // Redirects mean the session expired, they are treated as errors
+    const response = await fetch(url, { redirect: "error" });
+    if (response.ok) {
+      const draft = await response.json();
+      if (JSON.stringify(draft.fields) !== savedFields) {
+        data.draft = draft;
+      }
+    }
+  } catch (e) {
+    console.warn("Failed to fetch draft", e);
+  }
+  const interval = setInterval(async () => {
+    if (!form.isConnected) {
+      clearInterval(interval);
+      return;
+    }
+    const fields = JSON.stringify(data.fields);
+    if (fields === savedFields) return;
+    try {
+      const response = await fetch(url, {
+        method: "PUT",
+        headers: { "Content-Type": "application/json", ...csrfHeaders() },
+        body: JSON.stringify({ fields: data.fields }),
+        redirect: "error",
+      });
+      if (response.ok) {
+        savedFields = fields;
+        // The draft that was offered is replaced by the new one
+        data.draft = null;
+      }
+    } catch (e) {
+      console.warn("Failed to save draft", e);
+    }
+  }, draftIntervalMs);
+}
+
+/**
+ * Replaces the fields of a form with those of its draft
+ * @param {{
+ *   fields: Record<string, any>
+ *   draft: {fields: Record<string, any>} | null
+ * }} data
+ */
+function restoreDraft(data) {
+  for (const [name, value] of Object.entries(data.draft?.fields ?? {})) {
+    if (name in data.fields) {
+      data.fields[name] = value;
+    }
+  }
+  data.draft = null;
+}
+
+/**
+ * @param {string} url
+ * @param {{draft: any}} data
+ */
+async function discardDraft(url, data) {
+  data.draft = null;
+  await fetch(url, { method: "DELETE", headers: csrfHeaders(), redirect: "error" }).catch((e) =>
+    console.warn("Failed to discard draft", e),
+  );
 }
 
 document.addEventListener("alpine:init", () => {
@@ -46,6 +199,56 @@ document.addEventListener("alpine:init", () => {
     },
   }));
 
+  Alpine.data("repeater", (fieldName, subFields = []) => ({
+    /** @returns {Record<string, string | boolean>[]} */
+    get items() {
+      return this.$data.fields[fieldName];
+    },
+    get valid() {
+      return this.$data.validationErrors?.[fieldName] == undefined;
+    },
+    get error() {
+      return this.$data.validationErrors?.[fieldName];
+    },
+    subFieldName(index, subField) {
+      return `${fieldName}[${index}].${subField}`;
+    },
+    subFieldValid(index, subField) {
+      return (
+        this.$data.validationErrors?.[this.subFieldName(index, subField)] ==
+        undefined
+      );
+    },
+    subFieldError(index, subField) {
+      return this.$data.validationErrors?.[this.subFieldName(index, subField)];
+    },
+    add() {
+      const item = {};
+      for (const subField of subFields) {
+        item[subField.name] = subField.type === "checkbox" ? false : "";
+      }
+      this.items.push(item);
+    },
+    remove(index) {
+      this.items.splice(index, 1);
+      this.$dispatch("validate");
+    },
+    move(index, offset) {
+      const [item] = this.items.splice(index, 1);
+      this.items.splice(index + offset, 0, item);
+      this.$dispatch("validate");
+    },
+    init() {
+      for (const item of this.items) {
+        for (const subField of subFields) {
+          if (subField.type === "checkbox") {
+            item[subField.name] = item[subField.name] === "true";
+          }
+        }
+      }
+    },
+  }));
+
   Alpine.data("toast", ({ durationMs } = {}) => ({
     init() {
       /** @type {HTMLElement} */
@@ -77,3 +280,145 @@ document.addEventListener("alpine:init", () => {
     },
   }));
 });
+
+/**
+ * @param {string} value base64url encoded bytes
+ * @returns {ArrayBuffer}
+ */
+function base64UrlToBuffer(value) {
+  const base64 = value.replace(/-/g, "+").replace(/_/g, "/");
+  const padded = base64.padEnd(base64.length + ((4 - (base64.length % 4)) % 4), "=");
+  return Uint8Array.from(atob(padded), (c) => c.charCodeAt(0)).buffer;
+}
+
+/**
+ * @param {ArrayBuffer} buffer
+ * @returns {string} base64url encoded bytes
+ */
+function bufferToBase64Url(buffer) {
+  const binary = String.fromCharCode(...new Uint8Array(buffer));
+  return btoa(binary).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+}
+
+/**
+ * Posts a credential created by the browser and follows the redirect in the response
+ * @param {string} url
+ * @param {Record<string, any>} credential
+ * @returns {Promise<string>} error message when it failed
+ */
+async function finishPasskey(url, credential) {
+  const response = await fetch(url, {
+    method: "POST",
+    headers: { "Content-Type": "application/json", ...csrfHeaders() },
+    body: JSON.stringify(credential),
+    redirect: "error",
+  });
+  if (!response.ok) {
+    return await response.text();
+  }
+  const { redirect } = await response.json();
+  window.location.href = redirect;
+  return "";
+}
+
+/**
+ * Asks the browser for a passkey of this site and logs in with it
+ * @returns {Promise<string>} error message when it failed
+ */
+async function signInWithPasskey() {
+  if (!window.PublicKeyCredential) {
+    return t("Your browser does not support passkeys");
+  }
+  try {
+    const response = await fetch("/login/passkey/begin", {
+      method: "POST",
+      headers: csrfHeaders(),
+      redirect: "error",
+    });
+    if (!response.ok) {
+      return t("Something went wrong, please try again");
+    }
+    const { publicKey } = await response.json();
+    publicKey.challenge = base64UrlToBuffer(publicKey.challenge);
+    publicKey.allowCredentials = (publicKey.allowCredentials ?? []).map((credential) => ({
+      ...credential,
+      id: base64UrlToBuffer(credential.id),
+    }));
+    const credential = await navigator.credentials.get({ publicKey });
+    return await finishPasskey("/login/passkey/finish", {
+      id: credential.id,
+      rawId: bufferToBase64Url(credential.rawId),
+      type: credential.type,
+      response: {
+        clientDataJSON: bufferToBase64Url(credential.response.clientDataJSON),
+        authenticatorData: bufferToBase64Url(credential.response.authenticatorData),
+        signature: bufferToBase64Url(credential.response.signature),
+        userHandle: credential.response.userHandle
+          ? bufferToBase64Url(credential.response.userHandle)
+          : null,
+      },
+    });
+  } catch (e) {
+    console.warn("Passkey login failed", e);
+    return t("Logging in with a passkey was cancelled or failed");
+  }
+}
+
+/**
+ * Creates a new passkey for the user that is logged in
+ * @param {string} name
+ * @returns {Promise<string>} error message when it failed
+ */
+async function registerPasskey(name) {
+  if (!window.PublicKeyCredential) {
+    return t("Your browser does not support passkeys");
+  }
+  try {
+    const response = await fetch("/account/passkeys/begin", {
+      method: "POST",
+      headers: csrfHeaders(),
+      redirect: "error",
+    });
+    if (!response.ok) {
+      return t("Something went wrong, please try again");
+    }
+    const { publicKey } = await response.json();
+    publicKey.challenge = base64UrlToBuffer(publicKey.challenge);
+    publicKey.user.id = base64UrlToBuffer(publicKey.user.id);
+    publicKey.excludeCredentials = (publicKey.excludeCredentials ?? []).map((credential) => ({
+      ...credential,
+      id: base64UrlToBuffer(credential.id),
+    }));
+    const credential = await navigator.credentials.create({ publicKey });
+    return await finishPasskey(`/account/passkeys/finish?${new URLSearchParams({ name })}`, {
+      id: credential.id,
+      rawId: bufferToBase64Url(credential.rawId),
+      type: credential.type,
+      response: {
+        clientDataJSON: bufferToBase64Url(credential.response.clientDataJSON),
+        attestationObject: bufferToBase64Url(credential.response.attestationObject),
+        transports: credential.response.getTransports?.() ?? [],
+      },
+    });
+  } catch (e) {
+    console.warn("Passkey registration failed", e);
+    return t("Adding the passkey was cancelled or failed");
+  }
+}
+
+// Throttled requests render a message explaining when to try again
+// and requests with an invalid csrf token render a page asking to reload
+document.addEventListener("htmx:beforeSwap", (evt) => {
+  if (evt.detail.xhr.status === 429 || evt.detail.xhr.status === 403) {
+    evt.detail.shouldSwap = true;
+    evt.detail.isError = false;
+  }
+});
+
+// Links that htmx loads on mousedown only follow their href when opened in a new tab,
+// the content security policy does not allow inline onclick handlers for this
+document.addEventListener("click", (evt) => {
+  if (!evt.metaKey && evt.target.closest("[data-prevent-click]")) {
+    evt.preventDefault();
+  }
+});
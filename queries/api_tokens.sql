@@ -0,0 +1,39 @@
+-- name: InsertApiToken :one
+insert into api_tokens (
+  user_id,
+  name,
+  token_hash,
+  scopes,
+  expires_at
+) values ($1, $2, $3, $4, $5) returning id;
+
+-- name: GetApiTokenByHash :one
+select
+  api_tokens.*,
+  users.role,
+  coalesce(role_settings.require_two_factor, false)::boolean as require_two_factor,
+  (user_totps.confirmed_at is not null)::boolean as has_two_factor
+from api_tokens
+join users on users.id = api_tokens.user_id
+left join role_settings on role_settings.role = users.role
+left join user_totps on user_totps.user_id = api_tokens.user_id
+where token_hash = $1
+limit 1;
+
+-- name: GetUserApiTokens :many
+select
+  *
+from api_tokens
+where user_id = $1
+order by created_at desc;
+
+-- name: TouchApiToken :exec
+update api_tokens set
+  last_used_at = now()
+where id = $1
+  and (last_used_at is null or last_used_at < now() - interval '1 minute');
+
+-- name: DeleteUserApiToken :execrows
+delete from api_tokens
+where id = $1
+  and user_id = $2;
@@ -21,13 +21,15 @@ with max_order as (
 ) insert into assignments(
   name,
   "type",
-  "order"
-) values ($1, $2, (select "order" + 1 from max_order)) returning id;
+  "order",
+  answer_options
+) values ($1, $2, (select "order" + 1 from max_order), $3) returning id;
 
 
 -- name: UpdateAssignment :exec
 update assignments set
   name = coalesce(sqlc.narg('name'), name),
   "type" = coalesce(sqlc.narg('type'), "type"),
-  "order" = coalesce(cast(sqlc.narg('order') as int4), "order")
+  "order" = coalesce(cast(sqlc.narg('order') as int4), "order"),
+  answer_options = sqlc.arg('answer_options')
 where id = $1;
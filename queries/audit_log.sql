@@ -0,0 +1,8 @@
+-- name: InsertAuditLog :exec
+insert into audit_log (
+  actor_id,
+  user_id,
+  method,
+  path,
+  status
+) values ($1, $2, $3, $4, $5);
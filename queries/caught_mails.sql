@@ -0,0 +1,22 @@
+-- name: InsertCaughtMail :one
+insert into caught_mails (
+  from_address,
+  to_addresses,
+  cc_addresses,
+  subject,
+  plain_text,
+  html
+) values ($1, $2, $3, $4, $5, $6) returning id;
+
+-- name: GetCaughtMail :one
+select * from caught_mails
+where id = $1
+limit 1;
+
+-- name: DeleteCaughtMails :execrows
+delete from caught_mails;
+
+-- name: getCaughtMailsPage :many
+select * from caught_mails
+order by created_at desc, id desc
+limit $1 offset $2;
@@ -0,0 +1,24 @@
+-- name: UpsertFormDraft :exec
+insert into form_drafts (
+  user_id,
+  resource,
+  row_id,
+  fields
+) values ($1, $2, $3, $4)
+on conflict (user_id, resource, row_id) do update set
+  fields = excluded.fields;
+
+-- name: GetFormDraft :one
+select
+  *
+from form_drafts
+where user_id = $1
+  and resource = $2
+  and row_id = $3
+limit 1;
+
+-- name: DeleteFormDraft :exec
+delete from form_drafts
+where user_id = $1
+  and resource = $2
+  and row_id = $3;
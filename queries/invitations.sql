@@ -0,0 +1,91 @@
+-- name: insertInvitedUser :one
+insert into users(
+  name,
+  email,
+  date_of_birth,
+  role,
+  status
+) values ($1, $2, $3, $4, 'pending') returning id;
+
+-- name: InsertInvitation :one
+insert into invitations (
+  jti,
+  user_id,
+  invited_by,
+  expires_at
+) values ($1, $2, $3, $4) returning id;
+
+-- name: GetPendingInvitations :many
+select
+  invitations.id,
+  invitations.user_id,
+  invitations.created_at,
+  invitations.expires_at,
+  users.name,
+  users.email,
+  inviters.name as invited_by_name
+from invitations
+join users on users.id = invitations.user_id
+left join users inviters on inviters.id = invitations.invited_by
+where invitations.accepted_at is null
+order by invitations.created_at desc;
+
+-- name: GetInvitationByJti :one
+select
+  invitations.id,
+  invitations.user_id,
+  invitations.expires_at,
+  invitations.accepted_at,
+  users.name,
+  users.email,
+  users.date_of_birth
+from invitations
+join users on users.id = invitations.user_id
+where invitations.jti = $1
+limit 1;
+
+-- name: GetPendingInvitation :one
+select
+  invitations.id,
+  invitations.user_id,
+  users.name,
+  users.email
+from invitations
+join users on users.id = invitations.user_id
+where invitations.id = $1
+  and invitations.accepted_at is null
+limit 1;
+
+-- name: RenewInvitation :execrows
+update invitations set
+  jti = $2,
+  expires_at = $3
+where id = $1
+  and accepted_at is null;
+
+-- name: AcceptInvitation :one
+with accepted as (
+  update invitations set
+    accepted_at = now()
+  where invitations.jti = $1
+    and invitations.accepted_at is null
+    and invitations.expires_at > now()
+  returning invitations.user_id
+)
+update users set
+  name = $2,
+  date_of_birth = $3,
+  status = 'active'
+from accepted
+where users.id = accepted.user_id
+returning users.id;
+
+-- name: DeleteInvitedUser :execrows
+delete from users
+where status = 'pending'
+  and id = (
+    select user_id
+    from invitations
+    where invitations.id = $1
+      and accepted_at is null
+  );
@@ -0,0 +1,21 @@
+-- name: InsertLoginLink :one
+insert into login_links (
+  jti,
+  user_id,
+  expires_at
+) values ($1, $2, $3) returning id;
+
+-- name: GetLoginLink :one
+select
+  *
+from login_links
+where jti = $1
+limit 1;
+
+-- name: ConsumeLoginLink :one
+update login_links set
+  used_at = now()
+where jti = $1
+  and used_at is null
+  and expires_at > now()
+returning user_id;
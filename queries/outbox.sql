@@ -0,0 +1,57 @@
+-- name: InsertOutboxMessage :one
+insert into outbox (
+  from_address,
+  to_addresses,
+  cc_addresses,
+  subject,
+  plain_text,
+  html,
+  expires_at
+) values ($1, $2, $3, $4, $5, $6, $7) returning id;
+
+-- name: ClaimOutboxMessages :many
+update outbox set
+  attempts = attempts + 1,
+  next_attempt_at = sqlc.arg(lease_until)
+where id in (
+  select id
+  from outbox
+  where status = 'pending'
+    and next_attempt_at <= now()
+  order by next_attempt_at
+  limit sqlc.arg(max_messages)
+  for update skip locked
+)
+returning *;
+
+-- name: MarkOutboxMessageSent :exec
+update outbox set
+  status = 'sent',
+  sent_at = now(),
+  last_error = null
+where id = $1;
+
+-- name: MarkOutboxMessageFailed :exec
+update outbox set
+  status = $2,
+  last_error = $3,
+  next_attempt_at = $4
+where id = $1;
+
+-- name: RetryOutboxMessage :execrows
+update outbox set
+  status = 'pending',
+  attempts = 0,
+  next_attempt_at = now()
+where id = $1
+  and status = 'failed';
+
+-- name: DeleteSentOutboxMessages :execrows
+delete from outbox
+where status = 'sent'
+  and sent_at < $1;
+
+-- name: getOutboxPage :many
+select * from outbox
+order by created_at desc
+limit $1 offset $2;
@@ -0,0 +1,42 @@
+-- name: InsertPasskey :one
+insert into passkeys (
+  user_id,
+  name,
+  credential_id,
+  credential
+) values ($1, $2, $3, $4) returning id;
+
+-- name: GetUserPasskeys :many
+select
+  *
+from passkeys
+where user_id = $1
+order by created_at;
+
+-- name: UpdatePasskeyCredential :exec
+update passkeys set
+  credential = $2,
+  last_used_at = now()
+where credential_id = $1;
+
+-- name: DeleteUserPasskey :execrows
+delete from passkeys
+where id = $1
+  and user_id = $2;
+
+-- name: InsertPasskeyCeremony :exec
+insert into passkey_ceremonies (
+  id,
+  state,
+  expires_at
+) values ($1, $2, $3);
+
+-- name: TakePasskeyCeremony :one
+delete from passkey_ceremonies
+where id = $1
+  and expires_at > now()
+returning state;
+
+-- name: DeleteExpiredPasskeyCeremonies :exec
+delete from passkey_ceremonies
+where expires_at <= now();
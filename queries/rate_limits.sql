@@ -0,0 +1,14 @@
+-- name: HitRateLimit :one
+insert into rate_limits (
+  key,
+  hits,
+  reset_at
+) values (sqlc.arg(key), 1, sqlc.arg(reset_at))
+on conflict (key) do update set
+  hits = case when rate_limits.reset_at <= sqlc.arg(now) then 1 else rate_limits.hits + 1 end,
+  reset_at = case when rate_limits.reset_at <= sqlc.arg(now) then excluded.reset_at else rate_limits.reset_at end
+returning hits, reset_at;
+
+-- name: DeleteEndedRateLimits :exec
+delete from rate_limits
+where reset_at <= $1;
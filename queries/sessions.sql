@@ -0,0 +1,71 @@
+-- name: InsertSession :one
+insert into sessions (
+  jti,
+  user_id,
+  user_agent,
+  ip_address,
+  refresh_token_hash,
+  impersonator_id
+) values ($1, $2, $3, $4, $5, $6) returning id;
+
+-- name: GetSessionByJti :one
+select
+  sessions.*,
+  users.role,
+  users.email,
+  users.locale,
+  coalesce(role_settings.require_two_factor, false)::boolean as require_two_factor,
+  (user_totps.confirmed_at is not null)::boolean as has_two_factor
+from sessions
+join users on users.id = sessions.user_id
+left join role_settings on role_settings.role = users.role
+left join user_totps on user_totps.user_id = sessions.user_id
+where jti = $1
+limit 1;
+
+-- name: TouchSession :exec
+update sessions set
+  last_seen_at = now()
+where id = $1
+  and last_seen_at < now() - interval '1 minute';
+
+-- name: RotateRefreshToken :execrows
+update sessions set
+  previous_refresh_token_hash = refresh_token_hash,
+  refresh_token_hash = sqlc.arg(new_hash),
+  refreshed_at = now()
+where id = $1
+  and refresh_token_hash = sqlc.arg(current_hash);
+
+-- name: GetUserSessions :many
+select
+  *
+from sessions
+where user_id = $1
+order by last_seen_at desc;
+
+-- name: getSessionsPage :many
+select
+  sessions.*,
+  users.email
+from sessions
+join users on users.id = sessions.user_id
+order by sessions.last_seen_at desc
+limit $1 offset $2;
+
+-- name: DeleteSession :execrows
+delete from sessions
+where id = $1;
+
+-- name: DeleteSessionByJti :exec
+delete from sessions
+where jti = $1;
+
+-- name: DeleteUserSession :execrows
+delete from sessions
+where id = $1
+  and user_id = $2;
+
+-- name: DeleteUserSessions :execrows
+delete from sessions
+where user_id = $1;
@@ -0,0 +1,71 @@
+-- name: GetUserTotp :one
+select
+  *
+from user_totps
+where user_id = $1
+limit 1;
+
+-- name: UpsertUserTotp :exec
+insert into user_totps (
+  user_id,
+  secret_encrypted
+) values ($1, $2)
+on conflict (user_id) do update set
+  secret_encrypted = excluded.secret_encrypted,
+  created_at = now(),
+  confirmed_at = null,
+  last_used_step = 0;
+
+-- name: ConfirmUserTotp :execrows
+update user_totps set
+  confirmed_at = now()
+where user_id = $1
+  and confirmed_at is null;
+
+-- name: UseTotpStep :execrows
+update user_totps set
+  last_used_step = $2
+where user_id = $1
+  and last_used_step < $2;
+
+-- name: DeleteUserTotp :exec
+delete from user_totps
+where user_id = $1;
+
+-- name: InsertRecoveryCode :exec
+insert into recovery_codes (
+  user_id,
+  code_hash
+) values ($1, $2);
+
+-- name: DeleteRecoveryCodes :exec
+delete from recovery_codes
+where user_id = $1;
+
+-- name: ConsumeRecoveryCode :execrows
+update recovery_codes set
+  used_at = now()
+where user_id = $1
+  and code_hash = $2
+  and used_at is null;
+
+-- name: CountUnusedRecoveryCodes :one
+select
+  count(*)
+from recovery_codes
+where user_id = $1
+  and used_at is null;
+
+-- name: GetRoleSettings :many
+select
+  *
+from role_settings
+order by role;
+
+-- name: UpsertRoleSetting :exec
+insert into role_settings (
+  role,
+  require_two_factor
+) values ($1, $2)
+on conflict (role) do update set
+  require_two_factor = excluded.require_two_factor;
@@ -20,6 +20,13 @@ where email = @email
 limit 1;
 
 
+-- name: GetActiveUsers :many
+select
+  *
+from displayable_users
+where status = 'active'
+order by id;
+
 -- name: UserWithEmailExists :one
 select exists(
   select
@@ -42,6 +49,19 @@ update users set
   date_of_birth=$4
 where id = $1;
 
+-- name: UpdateUserLocale :exec
+update users set
+  locale = $2
+where id = $1;
+
+-- name: HasValidReloginToken :one
+select exists(
+  select
+  from relogin_tokens
+  where user_id = $1
+    and created_at > sqlc.arg(created_after)
+);
+
 -- name: InsertReloginToken :one
 insert into relogin_tokens (
   user_id,
@@ -52,4 +72,15 @@ insert into relogin_tokens (
 delete from relogin_tokens
 where token = $1
   and user_id = $2
-  and created_at > sqlc.arg(created_after);
+  and created_at > sqlc.arg(created_after)
+  and attempts < sqlc.arg(max_attempts);
+
+-- name: IncrementReloginTokenAttempts :exec
+update relogin_tokens set
+  attempts = attempts + 1
+where user_id = $1;
+
+-- name: DeleteStaleReloginTokens :exec
+delete from relogin_tokens
+where user_id = $1
+  or created_at < sqlc.arg(created_before);
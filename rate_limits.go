@@ -0,0 +1,192 @@
+package main
+
+import (
+	"log"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/pkg/i18n"
+	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/Kavantix/go-form/pkg/ratelimit"
+	"github.com/Kavantix/go-form/templates"
+	"github.com/labstack/echo/v4"
+)
+
+// keyedLimit is a limit that counts the hits of the key that is returned for a request,
+// requests for which the key is empty are not counted.
+type keyedLimit struct {
+	limit ratelimit.Limit
+	key   func(c echo.Context) string
+}
+
+func perIp(limit ratelimit.Limit) keyedLimit {
+	return keyedLimit{limit: limit, key: func(c echo.Context) string { return clientIp(c) }}
+}
+
+func perEmail(limit ratelimit.Limit) keyedLimit {
+	return keyedLimit{limit: limit, key: func(c echo.Context) string {
+		return strings.ToLower(strings.TrimSpace(c.FormValue("email")))
+	}}
+}
+
+func perUser(limit ratelimit.Limit) keyedLimit {
+	return keyedLimit{limit: limit, key: func(c echo.Context) string {
+		userId, ok := c.Get("UserId").(int32)
+		if !ok {
+			return ""
+		}
+		return strconv.Itoa(int(userId))
+	}}
+}
+
+// perPendingTwoFactorUser counts the hits of the user that is asked for a second factor,
+// so guessing codes from many addresses is limited as well.
+func perPendingTwoFactorUser(limit ratelimit.Limit) keyedLimit {
+	return keyedLimit{limit: limit, key: func(c echo.Context) string {
+		userId, err := tryGetTwoFactorPendingUserId(c)
+		if err != nil {
+			return ""
+		}
+		return strconv.Itoa(int(userId))
+	}}
+}
+
+// perCookieUser counts the hits of the user of the auth cookie, which may be expired
+func perCookieUser(limit ratelimit.Limit) keyedLimit {
+	return keyedLimit{limit: limit, key: func(c echo.Context) string {
+		userId, err := tryGetCookieUserId(c)
+		if err != nil {
+			return ""
+		}
+		return strconv.Itoa(int(userId))
+	}}
+}
+
+var (
+	loginLimits = []keyedLimit{
+		perIp(ratelimit.Limit{Name: "login_ip", Max: 10, Window: time.Minute * 15}),
+		// Every login sends a mail, so the mails a user receives are limited as well
+		perEmail(ratelimit.Limit{Name: "login_email", Max: 3, Window: time.Minute * 15}),
+	}
+	reloginLimits = []keyedLimit{
+		perIp(ratelimit.Limit{Name: "relogin_ip", Max: 20, Window: time.Minute * 15}),
+		// Requesting a token sends a mail and entering one guesses it, both are limited per user
+		perCookieUser(ratelimit.Limit{Name: "relogin_user", Max: 10, Window: time.Minute * 15}),
+	}
+	passkeyLimits = []keyedLimit{
+		perIp(ratelimit.Limit{Name: "passkey_ip", Max: 20, Window: time.Minute * 15}),
+	}
+	loginLinkLimits = []keyedLimit{
+		perIp(ratelimit.Limit{Name: "loginlink_ip", Max: 20, Window: time.Minute * 15}),
+	}
+	invitationLimits = []keyedLimit{
+		perIp(ratelimit.Limit{Name: "invitation_ip", Max: 20, Window: time.Minute * 15}),
+	}
+	twoFactorLimits = []keyedLimit{
+		perIp(ratelimit.Limit{Name: "2fa_ip", Max: 10, Window: time.Minute * 15}),
+		perPendingTwoFactorUser(ratelimit.Limit{Name: "2fa_user", Max: 5, Window: time.Minute * 15}),
+	}
+	validateLimits = []keyedLimit{
+		perUser(ratelimit.Limit{Name: "validate_user", Max: 120, Window: time.Minute}),
+	}
+	cspReportLimits = []keyedLimit{
+		perIp(ratelimit.Limit{Name: "csp_report_ip", Max: 60, Window: time.Minute}),
+	}
+)
+
+func ResolveRateLimitStore(queries *database.Queries) ratelimit.Store {
+	store := LookupEnv("RATE_LIMIT_STORE", "postgres")
+	switch store {
+	case "postgres":
+		return database.NewRateLimitStore(queries)
+	case "memory":
+		return ratelimit.NewMemoryStore()
+	default:
+		log.Fatalf("Unknown rate limit store `%s`\n", store)
+		return nil
+	}
+}
+
+// formatRetryAfter returns how long until a throttled request can be retried for users
+func formatRetryAfter(c echo.Context, retryAfter time.Duration) string {
+	minutes := int(math.Ceil(retryAfter.Minutes()))
+	if minutes <= 1 {
+		return i18n.T(c.Request().Context(), "a minute")
+	}
+	return i18n.T(c.Request().Context(), "%d minutes", minutes)
+}
+
+func loginThrottled(c echo.Context, retryAfter time.Duration) error {
+	return template(c, 429, templates.LoginMessage(formatRetryAfter(c, retryAfter)))
+}
+
+// reloginThrottled shows the error in the relogin form of the user of the auth cookie
+func reloginThrottled(queries *database.Queries) func(c echo.Context, retryAfter time.Duration) error {
+	return func(c echo.Context, retryAfter time.Duration) error {
+		user, err := tryGetUserFromCookie(c, queries, true)
+		if err != nil {
+			return htmxRedirect(c, "/login")
+		}
+		c.Response().Header().Set("HX-Reswap", "outerHTML")
+		return template(c, 429, templates.ReloginForm(user.Email, "", i18n.T(c.Request().Context(), "Too many attempts, please try again in %s.", formatRetryAfter(c, retryAfter))))
+	}
+}
+
+// passkeyThrottled responds with the message that the passkey script shows
+func passkeyThrottled(c echo.Context, retryAfter time.Duration) error {
+	return c.String(429, i18n.T(c.Request().Context(), "Too many attempts, please try again in %s.", formatRetryAfter(c, retryAfter)))
+}
+
+func loginLinkThrottled(c echo.Context, retryAfter time.Duration) error {
+	return template(c, 429, templates.LoginLinkError(i18n.T(c.Request().Context(), "Too many attempts, please try again in %s.", formatRetryAfter(c, retryAfter))))
+}
+
+func invitationThrottled(c echo.Context, retryAfter time.Duration) error {
+	return template(c, 429, templates.InvitationError(i18n.T(c.Request().Context(), "Too many attempts, please try again in %s.", formatRetryAfter(c, retryAfter))))
+}
+
+func twoFactorThrottled(c echo.Context, retryAfter time.Duration) error {
+	return template(c, 429, templates.TwoFactorChallenge(i18n.T(c.Request().Context(), "Too many attempts, please try again in %s.", formatRetryAfter(c, retryAfter))))
+}
+
+func validateThrottled(c echo.Context, retryAfter time.Duration) error {
+	return c.JSON(429, map[string]any{
+		"error": "too many requests",
+	})
+}
+
+func cspReportThrottled(c echo.Context, retryAfter time.Duration) error {
+	return c.NoContent(429)
+}
+
+// rateLimit rejects requests with onLimited once one of the limits is exceeded
+func rateLimit(limiter *ratelimit.Limiter, onLimited func(c echo.Context, retryAfter time.Duration) error, limits ...keyedLimit) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var retryAfter time.Duration
+			for _, limit := range limits {
+				key := limit.key(c)
+				if key == "" {
+					continue
+				}
+				allowed, limitRetryAfter, err := limiter.Allow(c.Request().Context(), limit.limit, key)
+				if err != nil {
+					return err
+				}
+				if !allowed {
+					logger.EchoWarn(c, "Rate limited", slog.String("limit", limit.limit.Name))
+					retryAfter = max(retryAfter, limitRetryAfter)
+				}
+			}
+			if retryAfter > 0 {
+				c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				return onLimited(c, retryAfter)
+			}
+			return next(c)
+		}
+	}
+}
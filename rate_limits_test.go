@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kavantix/go-form/pkg/ratelimit"
+	"github.com/labstack/echo/v4"
+)
+
+// rateLimitedServer responds to POST / with 204 until one of limits is exceeded, then with 429
+func rateLimitedServer(limits ...keyedLimit) *echo.Echo {
+	r := echo.New()
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore())
+	onLimited := func(c echo.Context, retryAfter time.Duration) error {
+		return c.NoContent(429)
+	}
+	r.POST("/", func(c echo.Context) error {
+		return c.NoContent(204)
+	}, rateLimit(limiter, onLimited, limits...))
+	return r
+}
+
+type testRequest struct {
+	remoteAddr    string
+	forwardedFor  string
+	email         string
+	expectedCode  int
+	expectedRetry string
+}
+
+func (tr testRequest) send(t *testing.T, r *echo.Echo) {
+	t.Helper()
+	form := url.Values{}
+	if tr.email != "" {
+		form.Set("email", tr.email)
+	}
+	request := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.RemoteAddr = tr.remoteAddr
+	if tr.forwardedFor != "" {
+		request.Header.Set("X-Forwarded-For", tr.forwardedFor)
+	}
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	if response.Code != tr.expectedCode {
+		t.Errorf("request %+v responded with %d, expected %d", tr, response.Code, tr.expectedCode)
+	}
+	if retryAfter := response.Header().Get("Retry-After"); retryAfter != tr.expectedRetry {
+		t.Errorf("request %+v has Retry-After `%s`, expected `%s`", tr, retryAfter, tr.expectedRetry)
+	}
+}
+
+func TestRateLimitPerIp(t *testing.T) {
+	r := rateLimitedServer(perIp(ratelimit.Limit{Name: "ip", Max: 2, Window: time.Minute * 15}))
+	for _, tr := range []testRequest{
+		{remoteAddr: "192.0.2.1:1000", expectedCode: 204},
+		{remoteAddr: "192.0.2.1:1001", expectedCode: 204},
+		{remoteAddr: "192.0.2.1:1002", expectedCode: 429, expectedRetry: "900"},
+		// Clients cannot pick another address without trusted proxies
+		{remoteAddr: "192.0.2.1:1003", forwardedFor: "198.51.100.1", expectedCode: 429, expectedRetry: "900"},
+		{remoteAddr: "192.0.2.2:1000", expectedCode: 204},
+	} {
+		tr.send(t, r)
+	}
+}
+
+func TestRateLimitPerIpBehindTrustedProxy(t *testing.T) {
+	r := rateLimitedServer(perIp(ratelimit.Limit{Name: "ip", Max: 1, Window: time.Minute}))
+	var err error
+	r.IPExtractor, err = ResolveIPExtractor("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("failed to resolve ip extractor: %s", err)
+	}
+	for _, tr := range []testRequest{
+		{remoteAddr: "10.0.0.1:1000", forwardedFor: "198.51.100.1", expectedCode: 204},
+		{remoteAddr: "10.0.0.1:1001", forwardedFor: "198.51.100.2", expectedCode: 204},
+		{remoteAddr: "10.0.0.2:1000", forwardedFor: "198.51.100.1", expectedCode: 429, expectedRetry: "60"},
+		// Only the proxies are trusted to forward addresses
+		{remoteAddr: "192.0.2.1:1000", forwardedFor: "198.51.100.3", expectedCode: 204},
+		{remoteAddr: "192.0.2.1:1001", forwardedFor: "198.51.100.4", expectedCode: 429, expectedRetry: "60"},
+	} {
+		tr.send(t, r)
+	}
+}
+
+func TestRateLimitPerEmail(t *testing.T) {
+	r := rateLimitedServer(perEmail(ratelimit.Limit{Name: "email", Max: 1, Window: time.Minute}))
+	for _, tr := range []testRequest{
+		{remoteAddr: "192.0.2.1:1000", email: "jane@example.com", expectedCode: 204},
+		// Another address does not reset the limit of the email
+		{remoteAddr: "192.0.2.2:1000", email: " Jane@Example.com", expectedCode: 429, expectedRetry: "60"},
+		{remoteAddr: "192.0.2.1:1001", email: "john@example.com", expectedCode: 204},
+		// Requests without an email are not counted
+		{remoteAddr: "192.0.2.1:1002", expectedCode: 204},
+		{remoteAddr: "192.0.2.1:1003", expectedCode: 204},
+	} {
+		tr.send(t, r)
+	}
+}
+
+func TestRateLimitRetriesAfterLongestLimit(t *testing.T) {
+	r := rateLimitedServer(
+		perIp(ratelimit.Limit{Name: "ip", Max: 1, Window: time.Minute}),
+		perEmail(ratelimit.Limit{Name: "email", Max: 1, Window: time.Minute * 15}),
+	)
+	for _, tr := range []testRequest{
+		{remoteAddr: "192.0.2.1:1000", email: "jane@example.com", expectedCode: 204},
+		{remoteAddr: "192.0.2.1:1001", email: "john@example.com", expectedCode: 429, expectedRetry: "60"},
+		{remoteAddr: "192.0.2.1:1002", email: "jane@example.com", expectedCode: 429, expectedRetry: "900"},
+	} {
+		tr.send(t, r)
+	}
+}
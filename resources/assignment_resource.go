@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 
 	"github.com/Kavantix/go-form/database"
 	. "github.com/Kavantix/go-form/interfaces"
+	"github.com/Kavantix/go-form/mails"
+	"github.com/Kavantix/go-form/pkg/i18n"
 	"github.com/Kavantix/go-form/templates/components"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -23,10 +26,10 @@ func NewAssignmentResource(queries *database.Queries) Resource[database.Assignme
 	}
 	r.tableConfig = NewResourceTableConfig(&r).
 		WithColumns([]ColumnConfig[database.Assignment]{
-			{Label: "Id", Value: func(user database.Assignment) string { return strconv.Itoa(int(user.Id)) }},
-			{Label: "Name", Value: func(user database.Assignment) string { return user.Name }},
-			{Label: "Type", Value: func(user database.Assignment) string { return user.Type }},
-			{Label: "Order", Value: func(row database.Assignment) string { return strconv.Itoa(int(row.Order)) }},
+			{Label: "Id", Value: func(ctx context.Context, user database.Assignment) string { return strconv.Itoa(int(user.Id)) }},
+			{Label: "Name", Value: func(ctx context.Context, user database.Assignment) string { return user.Name }},
+			{Label: "Type", Value: func(ctx context.Context, user database.Assignment) string { return i18n.T(ctx, user.Type) }},
+			{Label: "Order", Value: func(ctx context.Context, row database.Assignment) string { return strconv.Itoa(int(row.Order)) }},
 		}).
 		Build()
 
@@ -50,25 +53,40 @@ func (r assignmentResource) FetchRow(ctx context.Context, id int32) (*database.A
 	}
 }
 
-func (r assignmentResource) ParseRow(ctx context.Context, id *int, formFields map[string]string) (*database.Assignment, error) {
-	assignment := database.Assignment{}
-	if id != nil {
-		assignment.Id = int32(*id)
-	}
-	assignment.Name = formFields["name"]
-	assignment.Type = formFields["type"]
+func (r assignmentResource) ValidateRow(ctx context.Context, assignment *database.Assignment) error {
 	if assignment.Type == "sound" {
-		return &assignment, ValidationError{
+		return ValidationError{
 			FieldName: "type",
 			Reason:    errors.New("unsupported type"),
-			Message:   "Sound type is not supported yet",
+			Message:   i18n.T(ctx, "Sound type is not supported yet"),
+		}
+	}
+	if len(assignment.AnswerOptions) > 0 && !slices.ContainsFunc(assignment.AnswerOptions, func(option database.AnswerOption) bool { return option.Correct }) {
+		return ValidationError{
+			FieldName: "answer_options",
+			Reason:    errors.New("no correct answer option"),
+			Message:   i18n.T(ctx, "At least one answer option should be correct"),
 		}
 	}
-	return &assignment, nil
+	return nil
+}
+
+func (r assignmentResource) Notifications() []Notification[database.Assignment] {
+	return []Notification[database.Assignment]{
+		{
+			Event:      RowCreated,
+			Recipients: AllUsers[database.Assignment](r.queries),
+			Template: mails.NotificationTemplate{
+				Subject: "New assignment: {{.Row.Name}}",
+				Intro:   "{{.Actor}} created the {{t .Row.Type}} assignment {{.Row.Name}}.",
+				Button:  "View assignment",
+			},
+		},
+	}
 }
 
 func (r assignmentResource) CreateRow(ctx context.Context, assignment *database.Assignment) (int32, error) {
-	return r.queries.InsertAssignment(ctx, assignment.Name, assignment.Type)
+	return r.queries.InsertAssignment(ctx, assignment.Name, assignment.Type, assignment.AnswerOptions)
 }
 
 func (r assignmentResource) UpdateRow(ctx context.Context, assignment *database.Assignment) error {
@@ -77,6 +95,8 @@ func (r assignmentResource) UpdateRow(ctx context.Context, assignment *database.
 		Name:  pgtype.Text{String: assignment.Name, Valid: assignment.Name != ""},
 		Type:  pgtype.Text{String: assignment.Type, Valid: assignment.Type != ""},
 		Order: pgtype.Int4{Int32: assignment.Order, Valid: assignment.Order > 0},
+
+		AnswerOptions: assignment.AnswerOptions,
 	})
 }
 
@@ -89,6 +109,10 @@ func (r assignmentResource) FormConfig() FormConfig[database.Assignment] {
 				return fmt.Sprintf("/assignments/%d", row.Id)
 			}
 		},
+		Steps: []FormStep{
+			{Title: "Details", FieldNames: []string{"name", "type"}},
+			{Title: "Answers", FieldNames: []string{"answer_options"}},
+		},
 		Fields: [](FormField[database.Assignment]){
 			&components.TextFormFieldConfig[database.Assignment]{
 				FieldLabel:  "Name",
@@ -96,6 +120,7 @@ func (r assignmentResource) FormConfig() FormConfig[database.Assignment] {
 				Placeholder: "Enter a name",
 				Required:    true,
 				FieldValue:  func(row *database.Assignment) string { return row.Name },
+				FieldSetter: func(row *database.Assignment, value string) { row.Name = value },
 				FieldValidator: func(value string) string {
 					if len(value) < 10 {
 						return "Too short, minimum length is 10"
@@ -117,8 +142,47 @@ func (r assignmentResource) FormConfig() FormConfig[database.Assignment] {
 						Value: "sound",
 					},
 				},
-				Required:   true,
-				FieldValue: func(row *database.Assignment) string { return row.Type },
+				Required:    true,
+				FieldValue:  func(row *database.Assignment) string { return row.Type },
+				FieldSetter: func(row *database.Assignment, value string) { row.Type = value },
+			},
+			&components.RepeaterFormFieldConfig[database.Assignment]{
+				FieldLabel: "Answer options",
+				FieldName:  "answer_options",
+				AddLabel:   "Add answer option",
+				SubFields: []components.RepeaterSubField{
+					{
+						Label:       "Answer",
+						Name:        "label",
+						Placeholder: "Enter an answer",
+						Required:    true,
+					},
+					{
+						Label: "Correct",
+						Name:  "correct",
+						Type:  "checkbox",
+					},
+				},
+				MaxItems: 10,
+				FieldItems: func(row *database.Assignment) []map[string]string {
+					items := make([]map[string]string, len(row.AnswerOptions))
+					for i, option := range row.AnswerOptions {
+						items[i] = map[string]string{
+							"label":   option.Label,
+							"correct": strconv.FormatBool(option.Correct),
+						}
+					}
+					return items
+				},
+				FieldSetter: func(row *database.Assignment, items []map[string]string) {
+					row.AnswerOptions = make(database.AnswerOptions, len(items))
+					for i, item := range items {
+						row.AnswerOptions[i] = database.AnswerOption{
+							Label:   item["label"],
+							Correct: item["correct"] == "true",
+						}
+					}
+				},
 			},
 		},
 	}
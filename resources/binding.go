@@ -0,0 +1,76 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+
+	. "github.com/Kavantix/go-form/interfaces"
+	"github.com/Kavantix/go-form/pkg/i18n"
+)
+
+// BindRow reads the form fields of resource from values, validates them and binds them into row.
+// Parsing errors of fields and the ValidationError of a RowValidator are included in the returned validation errors,
+// which are keyed by field name and translated to the locale of ctx.
+// An error is only returned when binding could not be completed.
+func BindRow[T any](ctx context.Context, resource Resource[T], row *T, values url.Values) (map[string]string, error) {
+	formConfig := resource.FormConfig()
+	formFields, validationErrors := readFormFields(ctx, formConfig, values)
+	for _, field := range formConfig.Fields {
+		err := field.Bind(row, formFields)
+		if err == nil {
+			continue
+		}
+		var parsingErr ParsingError
+		if !errors.As(err, &parsingErr) {
+			parsingErr = ParsingError{
+				FieldName: field.Name(),
+				Reason:    err,
+				Message:   "Invalid value",
+			}
+		}
+		if _, hasError := validationErrors[parsingErr.FieldName]; !hasError {
+			validationErrors[parsingErr.FieldName] = i18n.T(ctx, parsingErr.Message)
+		}
+	}
+	if validator, ok := resource.(RowValidator[T]); ok {
+		err := validator.ValidateRow(ctx, row)
+		var validationErr ValidationError
+		if errors.As(err, &validationErr) {
+			validationErrors[validationErr.FieldName] = validationErr.Message
+		} else if err != nil {
+			return validationErrors, err
+		}
+	}
+	return validationErrors, nil
+}
+
+// readFormFields collects the values of all fields in formConfig from values and validates them.
+// Items of repeater fields are stored under their indexed names, see RepeaterKey.
+func readFormFields[T any](ctx context.Context, formConfig FormConfig[T], values url.Values) (formFields, validationErrors map[string]string) {
+	formFields = map[string]string{}
+	validationErrors = map[string]string{}
+	for _, field := range formConfig.Fields {
+		fieldName := field.Name()
+		if repeater, isRepeater := field.(RepeatedFormField[T]); isRepeater {
+			items := ParseRepeaterItems(values, fieldName, repeater.SubFieldNames())
+			for i, item := range items {
+				for subFieldName, value := range item {
+					formFields[RepeaterKey(fieldName, i, subFieldName)] = value
+				}
+				for subFieldName, validationError := range repeater.ValidateItem(ctx, item) {
+					validationErrors[RepeaterKey(fieldName, i, subFieldName)] = validationError
+				}
+			}
+			formFields[fieldName] = strconv.Itoa(len(items))
+		} else {
+			formFields[fieldName] = values.Get(fieldName)
+		}
+		validationError := field.Validator(ctx, formFields[fieldName])
+		if validationError != "" {
+			validationErrors[fieldName] = validationError
+		}
+	}
+	return formFields, validationErrors
+}
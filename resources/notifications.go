@@ -0,0 +1,173 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/mails"
+	"github.com/Kavantix/go-form/pkg/baseurl"
+	"github.com/Kavantix/go-form/pkg/i18n"
+)
+
+// ResourceEvent is a change to a row of a resource that users can be notified of
+type ResourceEvent string
+
+const (
+	RowCreated ResourceEvent = "created"
+	RowUpdated ResourceEvent = "updated"
+)
+
+// Recipients returns the users that are notified of a change to row
+type Recipients[T any] func(ctx context.Context, row *T) ([]database.DisplayableUser, error)
+
+// Notification mails Template to the Recipients when Event happens to a row,
+// the row is available in the template as `.Row`.
+type Notification[T any] struct {
+	Event      ResourceEvent
+	Recipients Recipients[T]
+	Template   mails.NotificationTemplate
+}
+
+// Notifier is implemented by resources that notify users of changes to their rows
+type Notifier[T any] interface {
+	Notifications() []Notification[T]
+}
+
+// AllUsers notifies every active user
+func AllUsers[T any](queries *database.Queries) Recipients[T] {
+	return func(ctx context.Context, row *T) ([]database.DisplayableUser, error) {
+		return queries.GetActiveUsers(ctx)
+	}
+}
+
+// AdminUsers notifies every active admin
+func AdminUsers[T any](queries *database.Queries) Recipients[T] {
+	return func(ctx context.Context, row *T) ([]database.DisplayableUser, error) {
+		users, err := queries.GetActiveUsers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		admins := []database.DisplayableUser{}
+		for _, user := range users {
+			if user.Role == database.RoleAdmin {
+				admins = append(admins, user)
+			}
+		}
+		return admins, nil
+	}
+}
+
+// NotificationRecipients names who a notification is sent to in a NotificationConfig
+type NotificationRecipients string
+
+const (
+	// DefaultRecipients are the Recipients of the Notification
+	DefaultRecipients NotificationRecipients = "default"
+	AllRecipients     NotificationRecipients = "all"
+	AdminRecipients   NotificationRecipients = "admins"
+	// NoRecipients disables the notification
+	NoRecipients NotificationRecipients = "none"
+)
+
+// NotificationConfig overrides the recipients of notifications by `<resource>.<event>`, like `assignments.created`,
+// notifications that are not in it are sent to their default recipients.
+type NotificationConfig map[string]NotificationRecipients
+
+// ParseNotificationConfig parses a comma separated list of `<resource>.<event>=<recipients>`,
+// like `assignments.created=admins,users.updated=none`.
+func ParseNotificationConfig(config string) (NotificationConfig, error) {
+	parsed := NotificationConfig{}
+	if strings.TrimSpace(config) == "" {
+		return parsed, nil
+	}
+	for _, entry := range strings.Split(config, ",") {
+		key, recipients, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("notification `%s` has no recipients", entry)
+		}
+		resource, event, ok := strings.Cut(key, ".")
+		if !ok || resource == "" || (ResourceEvent(event) != RowCreated && ResourceEvent(event) != RowUpdated) {
+			return nil, fmt.Errorf("notification `%s` is not `<resource>.created` or `<resource>.updated`", key)
+		}
+		switch NotificationRecipients(recipients) {
+		case DefaultRecipients, AllRecipients, AdminRecipients, NoRecipients:
+			parsed[key] = NotificationRecipients(recipients)
+		default:
+			return nil, fmt.Errorf("recipients `%s` of notification `%s` are not one of default/all/admins/none", recipients, key)
+		}
+	}
+	return parsed, nil
+}
+
+// configuredRecipients returns the recipients of notification of resource with config applied,
+// it returns nil when the notification is disabled.
+func configuredRecipients[T any](queries *database.Queries, config NotificationConfig, resource Resource[T], notification Notification[T]) Recipients[T] {
+	key := strings.TrimPrefix(resource.Location(nil), "/") + "." + string(notification.Event)
+	switch config[key] {
+	case AllRecipients:
+		return AllUsers[T](queries)
+	case AdminRecipients:
+		return AdminUsers[T](queries)
+	case NoRecipients:
+		return nil
+	default:
+		return notification.Recipients
+	}
+}
+
+// Notify mails the recipients of the notifications of resource for event, config can change the recipients.
+// The actor made the change and is not notified of it.
+// Recipients without a preferred locale get the mail in the locale of ctx,
+// failing to notify a recipient does not stop the others from being notified.
+func Notify[T any](ctx context.Context, queries *database.Queries, config NotificationConfig, resource Resource[T], event ResourceEvent, row *T, actor database.DisplayableUser) error {
+	notifier, ok := resource.(Notifier[T])
+	if !ok {
+		return nil
+	}
+	var errs []error
+	for _, notification := range notifier.Notifications() {
+		if notification.Event != event {
+			continue
+		}
+		getRecipients := configuredRecipients(queries, config, resource, notification)
+		if getRecipients == nil {
+			continue
+		}
+		recipients, err := getRecipients(ctx, row)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get recipients: %w", err))
+			continue
+		}
+		for _, recipient := range recipients {
+			if recipient.Id == actor.Id || recipient.Status != database.UserStatusActive {
+				continue
+			}
+			locale := i18n.FromContext(ctx)
+			if recipient.Locale.Valid && i18n.Supported(recipient.Locale.String) {
+				locale = recipient.Locale.String
+			}
+			mail, err := mails.Notification(mails.NotificationMailContent{
+				Template: notification.Template,
+				Data: mails.NotificationData{
+					User:  recipient,
+					Actor: actor.Name,
+					Row:   *row,
+				},
+				Link:   baseurl.Url(resource.Location(row), nil),
+				Locale: locale,
+			})
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			err = mail.SendTo(ctx, recipient.Email)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to notify user %d: %w", recipient.Id, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,39 @@
+package resources
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseNotificationConfig(t *testing.T) {
+	config, err := ParseNotificationConfig(" assignments.created=admins, users.updated=none,assignments.updated=default")
+	if err != nil {
+		t.Fatalf("failed to parse config: %s", err)
+	}
+	expected := NotificationConfig{
+		"assignments.created": AdminRecipients,
+		"users.updated":       NoRecipients,
+		"assignments.updated": DefaultRecipients,
+	}
+	if !maps.Equal(config, expected) {
+		t.Errorf("config is %v, expected %v", config, expected)
+	}
+
+	config, err = ParseNotificationConfig("")
+	if err != nil || len(config) != 0 {
+		t.Errorf("empty config parsed to %v, %v", config, err)
+	}
+
+	for _, invalid := range []string{
+		"assignments.created",
+		"assignments=all",
+		"assignments.deleted=all",
+		".created=all",
+		"assignments.created=everyone",
+	} {
+		_, err := ParseNotificationConfig(invalid)
+		if err == nil {
+			t.Errorf("parsed invalid config `%s`", invalid)
+		}
+	}
+}
@@ -7,31 +7,10 @@ import (
 	. "github.com/Kavantix/go-form/interfaces"
 )
 
-type ValidationError struct {
-	FieldName string
-	Message   string
-	Reason    error
-}
-
-func (e ValidationError) Error() string {
-	return fmt.Sprintf("Validation of field '%s' failed with error: %s", e.FieldName, e.Reason.Error())
-}
-
-type ParsingError struct {
-	FieldName string
-	Reason    error
-	Message   string
-}
-
-func (e ParsingError) Error() string {
-	return fmt.Sprintf("Parsing of field '%s' failed with error: %s", e.FieldName, e.Reason.Error())
-}
-
 type Resource[T any] interface {
 	Title() string
 	FetchPage(ctx context.Context, page, pageSize int) ([]T, error)
 	FetchRow(ctx context.Context, id int32) (*T, error)
-	ParseRow(ctx context.Context, id *int, formFields map[string]string) (*T, error)
 	CreateRow(ctx context.Context, row *T) (int32, error)
 	UpdateRow(ctx context.Context, row *T) error
 	TableConfig() TableConfig[T]
@@ -39,6 +18,13 @@ type Resource[T any] interface {
 	Location(row *T) string
 }
 
+// RowValidator is implemented by resources that validate a row after all its fields are bound,
+// for example to check uniqueness in the database.
+type RowValidator[T any] interface {
+	// ValidateRow returns a ValidationError when the row is invalid
+	ValidateRow(ctx context.Context, row *T) error
+}
+
 func NewResourceTableConfig[T any](resource Resource[T]) TableConfigBuilder[T] {
 	return NewTableConfig(func(row T) string { return resource.Location(&row) }).
 		WithTitle(resource.Title()).
@@ -2,13 +2,14 @@ package resources
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/Kavantix/go-form/database"
 	. "github.com/Kavantix/go-form/interfaces"
+	"github.com/Kavantix/go-form/mails"
+	"github.com/Kavantix/go-form/pkg/i18n"
 	"github.com/Kavantix/go-form/templates/components"
 	age "github.com/bearbin/go-age"
 )
@@ -24,11 +25,21 @@ func NewUserResource(queries *database.Queries) Resource[database.DisplayableUse
 	}
 	r.tableConfig = NewResourceTableConfig(&r).
 		WithColumns([](ColumnConfig[database.DisplayableUser]){
-			{Label: "Id", Value: func(user database.DisplayableUser) string { return strconv.Itoa(int(user.Id)) }},
-			{Label: "Name", Value: func(user database.DisplayableUser) string { return user.Name }},
-			{Label: "Email", Value: func(user database.DisplayableUser) string { return user.Email }},
-			{Label: "Age", Value: func(user database.DisplayableUser) string {
-				return fmt.Sprintf("%d years", age.Age(user.DateOfBirth))
+			{Label: "Id", Value: func(ctx context.Context, user database.DisplayableUser) string { return strconv.Itoa(int(user.Id)) }},
+			{Label: "Name", Value: func(ctx context.Context, user database.DisplayableUser) string { return user.Name }},
+			{Label: "Email", Value: func(ctx context.Context, user database.DisplayableUser) string { return user.Email }},
+			{Label: "Birthdate", Value: func(ctx context.Context, user database.DisplayableUser) string {
+				return i18n.FormatDate(i18n.FromContext(ctx), user.DateOfBirth)
+			}},
+			{Label: "Age", Value: func(ctx context.Context, user database.DisplayableUser) string {
+				return i18n.T(ctx, "%d years", age.Age(user.DateOfBirth))
+			}},
+			{Label: "Role", Value: func(ctx context.Context, user database.DisplayableUser) string { return i18n.T(ctx, user.Role) }},
+			{Label: "Status", Value: func(ctx context.Context, user database.DisplayableUser) string {
+				if user.Status == database.UserStatusPending {
+					return i18n.T(ctx, "Invited")
+				}
+				return i18n.T(ctx, "Active")
 			}},
 		}).
 		Build()
@@ -52,41 +63,35 @@ func (r userResource) FetchRow(ctx context.Context, id int32) (*database.Display
 	}
 }
 
-func (r userResource) ParseRow(ctx context.Context, id *int, formFields map[string]string) (*database.DisplayableUser, error) {
-	var err error
-	user := database.DisplayableUser{}
-	if id != nil {
-		user.Id = int32(*id)
-	}
-	user.Name = formFields["name"]
-	user.Email = formFields["email"]
+func (r userResource) ValidateRow(ctx context.Context, user *database.DisplayableUser) error {
 	emailExists, err := r.queries.UserWithEmailExists(ctx, user.Email, user.Id)
 	if err != nil {
-		return &user, fmt.Errorf("failed to check email for duplicates: %w", err)
+		return fmt.Errorf("failed to check email for duplicates: %w", err)
 	}
 	if emailExists {
-		return &user, ValidationError{
+		return ValidationError{
 			FieldName: "email",
 			Reason:    database.ErrDuplicateEmail,
-			Message:   "Email already used",
+			Message:   i18n.T(ctx, "Email already used"),
 		}
 	}
-	user.DateOfBirth, err = time.Parse("2006-01-02", formFields["date_of_birth"])
-	if err != nil {
-		return &user, ParsingError{
-			FieldName: "date_of_birth",
-			Reason:    err,
-			Message:   "Invalid date",
-		}
-	}
-	if age.Age(user.DateOfBirth) < 18 {
-		return &user, ValidationError{
-			FieldName: "date_of_birth",
-			Reason:    errors.New("age below 18"),
-			Message:   "Minimum age is 18",
-		}
+	return nil
+}
+
+func (r userResource) Notifications() []Notification[database.DisplayableUser] {
+	return []Notification[database.DisplayableUser]{
+		{
+			Event: RowUpdated,
+			Recipients: func(ctx context.Context, user *database.DisplayableUser) ([]database.DisplayableUser, error) {
+				return []database.DisplayableUser{*user}, nil
+			},
+			Template: mails.NotificationTemplate{
+				Subject: "Your account was updated",
+				Intro:   "{{.Actor}} updated the details of your account, your date of birth is now {{formatDate .Row.DateOfBirth}}.",
+				Outro:   "If you did not expect this change, please contact an admin.",
+			},
+		},
 	}
-	return &user, nil
 }
 
 func (r userResource) CreateRow(ctx context.Context, user *database.DisplayableUser) (int32, error) {
@@ -117,6 +122,7 @@ func (r userResource) FormConfig() FormConfig[database.DisplayableUser] {
 				FieldName:   "name",
 				Placeholder: "Enter a name",
 				FieldValue:  func(row *database.DisplayableUser) string { return row.Name },
+				FieldSetter: func(row *database.DisplayableUser, value string) { row.Name = value },
 				Required:    true,
 			},
 			&components.TextFormFieldConfig[database.DisplayableUser]{
@@ -125,15 +131,22 @@ func (r userResource) FormConfig() FormConfig[database.DisplayableUser] {
 				Placeholder: "Enter an email",
 				Type:        "email",
 				FieldValue:  func(row *database.DisplayableUser) string { return row.Email },
+				FieldSetter: func(row *database.DisplayableUser, value string) { row.Email = value },
 				Required:    true,
 			},
-			&components.TextFormFieldConfig[database.DisplayableUser]{
+			&components.DateFormFieldConfig[database.DisplayableUser]{
 				FieldLabel:  "Birthdate",
 				FieldName:   "date_of_birth",
 				Placeholder: "Enter the date of birth",
-				Type:        "date",
-				FieldValue:  func(row *database.DisplayableUser) string { return row.DateOfBirth.Format("2006-01-02") },
-				Required:    true,
+				FieldValue:  func(row *database.DisplayableUser) time.Time { return row.DateOfBirth },
+				FieldSetter: func(row *database.DisplayableUser, value time.Time) { row.DateOfBirth = value },
+				FieldValidator: func(value time.Time) string {
+					if age.Age(value) < 18 {
+						return "Minimum age is 18"
+					}
+					return ""
+				},
+				Required: true,
 			},
 		},
 	}
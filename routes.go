@@ -2,28 +2,36 @@ package main
 
 import (
 	"context"
-	"crypto/subtle"
 	"embed"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
+	"log/slog"
 	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 
+	"github.com/Kavantix/go-form/auth"
 	"github.com/Kavantix/go-form/database"
 
 	"github.com/Kavantix/go-form/interfaces"
+	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/Kavantix/go-form/pkg/ratelimit"
 	"github.com/Kavantix/go-form/resources"
 	"github.com/Kavantix/go-form/templates"
 	"github.com/getsentry/sentry-go"
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
 )
 
 type IsProduction bool
-type MailhogHost string
-type MailhogUser string
-type MailhogPassword string
+
+// DevMails configures the mails at /dev/mails, they are only registered when Enabled
+type DevMails struct {
+	Enabled        bool
+	CatcherEnabled bool
+}
 type GetUserFunc func(c echo.Context) (*database.DisplayableUser, error)
 
 type echoGroup = echo.Group
@@ -42,6 +50,12 @@ func RegisterRoutes(
 	disk interfaces.Disk,
 	isProduction IsProduction,
 	queries *database.Queries,
+	oidcProvider *auth.OidcProvider,
+	oidcAutoProvision OidcAutoProvision,
+	limiter *ratelimit.Limiter,
+	securityHeadersConfig SecurityHeadersConfig,
+	devMails DevMails,
+	notifications resources.NotificationConfig,
 ) {
 	r.Static("/storage", "./storage/public/")
 	jsDir, err := fs.Sub(publicJsFs, "public/js")
@@ -55,13 +69,32 @@ func RegisterRoutes(
 	}
 	r.StaticFS("/css", cssDir)
 	r.Use(setIsHtmx)
+	r.Use(setLocale)
+	r.Use(securityHeaders(securityHeadersConfig))
+	r.Use(csrfProtection(bool(isProduction)))
 	// r.POST("/upload", HandleUploadFile(disk))
 	// if disk, ok := disk.(interfaces.DirectUploadDisk); ok {
 	// 	r.GET("/upload-url", HandleGetUploadUrl(disk))
 	// }
-	r.Use(handleUnauthenticated)
-	r.GET("/loginlink", HandleLoginLink(bool(isProduction), queries))
-	authenticated, getUser := setupAuthenticatedGroup(r, queries)
+	r.GET("/.well-known/jwks.json", HandleJwks())
+	r.POST("/csp-report", HandleCspReport(), rateLimit(limiter, cspReportThrottled, cspReportLimits...))
+	r.Use(handleUnauthenticated(queries))
+	loginLinkRateLimit := rateLimit(limiter, loginLinkThrottled, loginLinkLimits...)
+	r.GET("/loginlink", HandleLoginLink(queries), loginLinkRateLimit)
+	r.POST("/loginlink", HandlePostLoginLink(bool(isProduction), queries), loginLinkRateLimit)
+	invitationRateLimit := rateLimit(limiter, invitationThrottled, invitationLimits...)
+	r.GET("/invitation", HandleInvitation(queries), invitationRateLimit)
+	r.POST("/invitation", HandlePostInvitation(bool(isProduction), queries), invitationRateLimit)
+	r.GET("/login/2fa", HandleTwoFactorChallenge())
+	r.POST("/login/2fa", HandlePostTwoFactorChallenge(bool(isProduction), queries), rateLimit(limiter, twoFactorThrottled, twoFactorLimits...))
+	passkeyRateLimit := rateLimit(limiter, passkeyThrottled, passkeyLimits...)
+	r.POST("/login/passkey/begin", HandleBeginPasskeyLogin(bool(isProduction), queries), passkeyRateLimit)
+	r.POST("/login/passkey/finish", HandleFinishPasskeyLogin(bool(isProduction), queries), passkeyRateLimit)
+	if oidcProvider != nil {
+		r.GET("/login/oidc", HandleOidcLogin(oidcProvider, bool(isProduction)))
+		r.GET("/login/oidc/callback", HandleOidcCallback(oidcProvider, oidcAutoProvision, bool(isProduction), queries))
+	}
+	authenticated, getUser := setupAuthenticatedGroup(r, queries, isProduction)
 	authenticated.GET("/users/me", func(e echo.Context) error {
 		user, err := getUser(e)
 		if err != nil {
@@ -69,14 +102,51 @@ func RegisterRoutes(
 		}
 		return e.JSONPretty(200, user, "  ")
 	})
-	r.GET("/logout", HandleLogout())
+	r.GET("/logout", HandleLogout(queries))
 	r.GET("/login", HandleLogin(queries))
-	r.POST("/login", HandlePostLogin(queries))
-	// r.GET("/relogin", HandleRelogin(queries))
-	// r.PUT("/relogin", HandlePutRelogin(bool(isProduction), queries))
+	r.POST("/login", HandlePostLogin(queries), rateLimit(limiter, loginThrottled, loginLimits...))
+	reloginRateLimit := rateLimit(limiter, reloginThrottled(queries), reloginLimits...)
+	r.GET("/relogin", HandleRelogin(queries), reloginRateLimit)
+	r.PUT("/relogin", HandlePutRelogin(bool(isProduction), queries), reloginRateLimit)
+
+	authenticated.GET("/account/sessions", HandleAccountSessions(queries))
+	authenticated.POST("/account/sessions/revoke", HandleRevokeAccountSessions(queries))
+	authenticated.POST("/account/sessions/:id/revoke", HandleRevokeAccountSession(queries))
+	authenticated.GET("/account/passkeys", HandleAccountPasskeys(queries))
+	authenticated.POST("/account/passkeys/begin", HandleBeginPasskeyRegistration(bool(isProduction), queries, getUser))
+	authenticated.POST("/account/passkeys/finish", HandleFinishPasskeyRegistration(bool(isProduction), queries, getUser))
+	authenticated.POST("/account/passkeys/:id/delete", HandleDeletePasskey(queries))
+	authenticated.GET("/account/tokens", HandleAccountApiTokens(queries))
+	authenticated.POST("/account/tokens", HandleCreateApiToken(queries))
+	authenticated.POST("/account/tokens/:id/revoke", HandleRevokeApiToken(queries))
+	authenticated.GET("/account/2fa", HandleAccountTwoFactor(queries))
+	authenticated.POST("/account/2fa/setup", HandleSetupTwoFactor(queries, getUser))
+	authenticated.POST("/account/2fa/confirm", HandleConfirmTwoFactor(queries, getUser))
+	authenticated.POST("/account/2fa/recovery-codes", HandleRegenerateRecoveryCodes(queries))
+	authenticated.POST("/account/2fa/disable", HandleDisableTwoFactor(queries))
+	authenticated.GET("/account/preferences", HandleAccountPreferences(queries))
+	authenticated.POST("/account/preferences", HandlePostAccountPreferences(queries))
+	authenticated.POST("/impersonation/stop", HandleStopImpersonation(bool(isProduction), queries))
+
+	admin := authenticated.Group("/admin", requireAdmin)
+	admin.GET("/sessions", HandleAdminSessions(queries))
+	admin.POST("/sessions/:id/revoke", HandleAdminRevokeSession(queries))
+	admin.POST("/users/:id/sessions/revoke", HandleAdminRevokeUserSessions(queries))
+	admin.POST("/users/:id/impersonate", HandleStartImpersonation(bool(isProduction), queries))
+	admin.GET("/roles", HandleAdminRoles(queries))
+	admin.POST("/roles", HandlePostAdminRoles(queries))
+	admin.GET("/invitations", HandleAdminInvitations(queries))
+	admin.POST("/invitations", HandleCreateInvitation(queries, getUser))
+	admin.POST("/invitations/:id/resend", HandleResendInvitation(queries, getUser))
+	admin.POST("/invitations/:id/revoke", HandleRevokeInvitation(queries))
+	admin.GET("/mails", HandleAdminMails(queries))
+	admin.POST("/mails/:id/retry", HandleRetryMail(queries))
+	if devMails.Enabled {
+		RegisterDevMails(authenticated, queries, devMails.CatcherEnabled)
+	}
 
-	RegisterResource(authenticated, resources.NewUserResource(queries))
-	RegisterResource(authenticated, resources.NewAssignmentResource(queries))
+	RegisterResource(authenticated, queries, getUser, limiter, notifications, resources.NewUserResource(queries))
+	RegisterResource(authenticated, queries, getUser, limiter, notifications, resources.NewAssignmentResource(queries))
 
 	r.GET("/", func(c echo.Context) error {
 		return c.Redirect(302, "/users")
@@ -96,30 +166,18 @@ func RegisterRoutes(
 	}
 }
 
-func RegisterMailhogProxy(
-	r *echo.Echo,
-	host MailhogHost,
-	correctUser MailhogUser,
-	correctPassword MailhogPassword,
-) {
-	mailhogUrl, err := url.Parse(fmt.Sprintf("http://%s:8025", host))
-	if err != nil {
-		log.Fatalf("Failed to construct mailhog url: %s\n", err)
+// RegisterDevMails registers the mail previews, and the mails that were caught when catcherEnabled, for admins.
+// They are only meant for local development and must not be registered anywhere else.
+func RegisterDevMails(authenticated AuthenticatedGroup, queries *database.Queries, catcherEnabled bool) {
+	g := authenticated.Group("/dev/mails", requireAdmin)
+	g.GET("", HandleDevMails(queries, catcherEnabled))
+	g.GET("/previews/:name", HandleMailPreview())
+	g.GET("/previews/:name/html", HandleMailPreviewHtml())
+	if catcherEnabled {
+		g.POST("/clear", HandleClearDevMails(queries))
+		g.GET("/:id", HandleDevMail(queries))
+		g.GET("/:id/html", HandleDevMailHtml(queries))
 	}
-	r.Group("/mailhog", middleware.BasicAuth(func(username, password string, ctx echo.Context) (bool, error) {
-		if subtle.ConstantTimeCompare([]byte(username), []byte(correctUser)) == 1 &&
-			subtle.ConstantTimeCompare([]byte(password), []byte(correctPassword)) == 1 {
-			return true, nil
-		}
-		return false, nil
-	}),
-		middleware.Proxy(middleware.NewRoundRobinBalancer([]*middleware.ProxyTarget{
-			{
-				URL: mailhogUrl,
-			},
-		})),
-	)
-
 }
 
 func setIsHtmx(next echo.HandlerFunc) echo.HandlerFunc {
@@ -144,52 +202,85 @@ func isHtmx(c echo.Context) bool {
 	return ok && isHtmx
 }
 
-func handleUnauthenticated(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		handlerErr := next(c)
-		_, hasUnauthenticated := c.Get("Unauthenticated").(bool)
-		if hasUnauthenticated {
-			if isHtmx(c) {
-				if c.Path() == "/logout" {
-					return htmxRedirect(c, "/login")
-				}
-				_, err := tryGetUserIdFromCookie(c, true)
-				if err != nil {
-					return htmxRedirect(c, "/login")
+func handleUnauthenticated(queries *database.Queries) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			handlerErr := next(c)
+			_, hasUnauthenticated := c.Get("Unauthenticated").(bool)
+			if hasUnauthenticated {
+				if isHtmx(c) {
+					if c.Path() == "/logout" {
+						return htmxRedirect(c, "/login")
+					}
+					// Revoked sessions need a new login
+					_, err := tryGetUserIdFromCookie(c, queries, true)
+					if err != nil {
+						return htmxRedirect(c, "/login")
+					}
+					c.Response().Header().Set("HX-Reswap", "innerHTML show:top")
+					c.Response().Header().Set("HX-Retarget", "#relogin")
+					return template(c, 422, templates.SessionExpired())
+				} else {
+					return c.Redirect(302, "/login")
 				}
-				c.Response().Header().Set("HX-Reswap", "innerHTML show:top")
-				c.Response().Header().Set("HX-Retarget", "#relogin")
-				return template(c, 422, templates.SessionExpired())
-			} else {
-				return c.Redirect(302, "/login")
 			}
+			return handlerErr
 		}
-		return handlerErr
 	}
 }
 
-func setupAuthenticatedGroup(r *echo.Echo, queries *database.Queries) (AuthenticatedGroup, GetUserFunc) {
+func setupAuthenticatedGroup(r *echo.Echo, queries *database.Queries, isProduction IsProduction) (AuthenticatedGroup, GetUserFunc) {
 	group := r.Group("", func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			userId, err := tryGetUserIdFromCookie(c, false)
+			if token, ok := bearerToken(c); ok {
+				apiToken, err := authenticateApiToken(c, queries, token)
+				if err != nil {
+					logger.EchoInfo(c, "Invalid api token", slog.String("reason", err.Error()))
+					c.Response().Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					return c.String(401, "invalid api token")
+				}
+				// Tokens of users that still have to set up a second factor cannot be used until they do
+				if apiToken.RequireTwoFactor && !apiToken.HasTwoFactor {
+					logger.EchoInfo(c, "Api token of user without required two-factor authentication", slog.Int("user", int(apiToken.UserID)))
+					return c.String(403, "two-factor authentication is required, set it up at /account/2fa")
+				}
+				scope := requiredApiTokenScope(c)
+				if !slices.Contains(apiToken.Scopes, scope) {
+					c.Response().Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, scope))
+					return c.String(403, fmt.Sprintf("api token is missing scope %s", scope))
+				}
+				c.Set("ApiTokenId", apiToken.Id)
+				setAuthenticatedUser(c, queries, apiToken.UserID, apiToken.Role == database.RoleAdmin)
+				return next(c)
+			}
+			session, err := tryGetSessionFromCookie(c, queries, false)
+			if errors.Is(err, auth.ErrTokenExpired) {
+				session, err = refreshSession(c, queries, bool(isProduction))
+				if err != nil {
+					logger.EchoInfo(c, "Failed to refresh session", slog.String("reason", err.Error()))
+				}
+			}
 			if err != nil {
 				c.Set("Unauthenticated", true)
 				return nil
 			}
-			hub := sentry.GetHubFromContext(c.Request().Context())
-			hub.Scope().SetUser(sentry.User{
-				ID: strconv.Itoa(int(userId)),
-			})
-			var user *database.DisplayableUser
-			c.Set("GetUser", func() (*database.DisplayableUser, error) {
-				if user == nil {
-					*user, err = queries.GetUser(c.Request().Context(), userId)
-					if err != nil {
-						return nil, err
-					}
+			err = queries.TouchSession(c.Request().Context(), session.Id)
+			if err != nil {
+				return fmt.Errorf("failed to touch session: %w", err)
+			}
+			c.Set("SessionId", session.Id)
+			c.Set("RequireTwoFactor", session.RequireTwoFactor)
+			setAuthenticatedUser(c, queries, session.UserID, session.Role == database.RoleAdmin)
+			setPreferredLocale(c, session.Locale)
+			if session.ImpersonatorID.Valid {
+				return impersonate(c, queries, session, next)
+			}
+			if session.RequireTwoFactor && !session.HasTwoFactor && !strings.HasPrefix(c.Path(), "/account/2fa") {
+				if isHtmx(c) {
+					return htmxRedirect(c, "/account/2fa")
 				}
-				return user, err
-			})
+				return c.Redirect(302, "/account/2fa")
+			}
 			return next(c)
 		}
 	})
@@ -203,14 +294,55 @@ func setupAuthenticatedGroup(r *echo.Echo, queries *database.Queries) (Authentic
 	return AuthenticatedGroup{group}, getUser
 }
 
-func RegisterResource[T any](e AuthenticatedGroup, resource resources.Resource[T]) {
+// setAuthenticatedUser makes the user that is logged in available to the handlers
+func setAuthenticatedUser(c echo.Context, queries *database.Queries, userId int32, isAdmin bool) {
+	c.Set("UserId", userId)
+	c.Set("IsAdmin", isAdmin)
+	c.SetRequest(c.Request().WithContext(context.WithValue(c.Request().Context(), "isAdmin", isAdmin)))
+	hub := sentry.GetHubFromContext(c.Request().Context())
+	hub.Scope().SetUser(sentry.User{
+		ID: strconv.Itoa(int(userId)),
+	})
+	var user *database.DisplayableUser
+	c.Set("GetUser", func() (*database.DisplayableUser, error) {
+		if user == nil {
+			fetchedUser, err := queries.GetUser(c.Request().Context(), userId)
+			if err != nil {
+				return nil, err
+			}
+			user = &fetchedUser
+		}
+		return user, nil
+	})
+}
+
+// authenticatedSessionId returns the id of the session of the user that is logged in,
+// it may only be used in handlers of the AuthenticatedGroup.
+func authenticatedSessionId(c echo.Context) int32 {
+	return c.Get("SessionId").(int32)
+}
+
+// authenticatedUserId returns the id of the user that is logged in,
+// it may only be used in handlers of the AuthenticatedGroup.
+func authenticatedUserId(c echo.Context) int32 {
+	return c.Get("UserId").(int32)
+}
+
+func RegisterResource[T any](e AuthenticatedGroup, queries *database.Queries, getUser GetUserFunc, limiter *ratelimit.Limiter, notifications resources.NotificationConfig, resource resources.Resource[T]) {
 	r := e.Group(resource.Location(nil))
+	validateRateLimit := rateLimit(limiter, validateThrottled, validateLimits...)
 	r.GET("", HandleResourceIndex(resource))
 	r.GET("/stream", HandleResourceIndexStream(resource))
 	r.GET("/:id", HandleResourceView(resource))
-	r.GET("/:id/validate", HandleValidateResource(resource))
+	r.GET("/:id/validate", HandleValidateResource(resource), validateRateLimit)
+	r.GET("/:id/draft", HandleGetFormDraft(queries, resource))
+	r.PUT("/:id/draft", HandlePutFormDraft(queries, resource))
+	r.DELETE("/:id/draft", HandleDeleteFormDraft(queries, resource))
 	r.GET("/create", HandleResourceCreate(resource))
-	r.GET("/validate", HandleValidateResource(resource))
-	r.POST("", HandleCreateResource(resource))
-	r.POST("/:id", HandleUpdateResource(resource))
+	r.GET("/validate", HandleValidateResource(resource), validateRateLimit)
+	r.GET("/draft", HandleGetFormDraft(queries, resource))
+	r.PUT("/draft", HandlePutFormDraft(queries, resource))
+	r.DELETE("/draft", HandleDeleteFormDraft(queries, resource))
+	r.POST("", HandleCreateResource(queries, notifications, resource, getUser))
+	r.POST("/:id", HandleUpdateResource(queries, notifications, resource, getUser))
 }
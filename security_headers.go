@@ -0,0 +1,252 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Kavantix/go-form/pkg/baseurl"
+	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/Kavantix/go-form/templates"
+	"github.com/a-h/templ"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// SecurityHeadersConfig is the set of security headers that is sent with every response
+type SecurityHeadersConfig struct {
+	// HstsMaxAge is the max-age of the Strict-Transport-Security header in seconds,
+	// it is only sent for https requests and 0 disables it
+	HstsMaxAge     int
+	FrameOptions   string
+	ReferrerPolicy string
+	// CspSources are the sources of each directive of the content security policy,
+	// the nonce of the request is added to script-src and style-src
+	CspSources map[string][]string
+	// CspReportOnly only reports violations of the content security policy instead of blocking them
+	CspReportOnly bool
+}
+
+// cspDirectives is the order the directives of the content security policy are written in
+var cspDirectives = []string{
+	"default-src",
+	"script-src",
+	"style-src",
+	"style-src-attr",
+	"img-src",
+	"font-src",
+	"connect-src",
+	"frame-ancestors",
+	"form-action",
+	"base-uri",
+	"object-src",
+}
+
+// defaultCspSources only allows our own resources and the sentry cdn, other scripts and styles are served from /js/vendor and /css/vendor.
+// Alpine expressions and htmx trigger filters are evaluated, which needs 'unsafe-eval',
+// and the style attributes Alpine and the templates set need style-src-attr 'unsafe-inline'.
+func defaultCspSources() map[string][]string {
+	return map[string][]string{
+		"default-src":     {"'self'"},
+		"script-src":      {"'self'", "'unsafe-eval'", "https://browser.sentry-cdn.com"},
+		"style-src":       {"'self'"},
+		"style-src-attr":  {"'unsafe-inline'"},
+		"img-src":         {"'self'", "data:", "blob:"},
+		"font-src":        {"'self'"},
+		"connect-src":     {"'self'"},
+		"frame-ancestors": {"'none'"},
+		"form-action":     {"'self'"},
+		"base-uri":        {"'self'"},
+		"object-src":      {"'none'"},
+	}
+}
+
+func ResolveSecurityHeadersConfig(isProduction IsProduction) SecurityHeadersConfig {
+	defaultHstsMaxAge := "0"
+	if isProduction {
+		defaultHstsMaxAge = "31536000"
+	}
+	hstsMaxAge, err := strconv.Atoi(LookupEnv("HSTS_MAX_AGE", defaultHstsMaxAge))
+	if err != nil || hstsMaxAge < 0 {
+		log.Fatalf("Invalid HSTS_MAX_AGE, it should be a number of seconds\n")
+	}
+	sources := defaultCspSources()
+	// The browser reports errors to sentry directly
+	if dsn, err := url.Parse(templates.FrontendSentryDSN); err == nil && dsn.Host != "" {
+		sources["connect-src"] = append(sources["connect-src"], fmt.Sprintf("%s://%s", dsn.Scheme, dsn.Host))
+	}
+	err = addCspSources(sources, LookupEnv("CSP_SOURCES", ""))
+	if err != nil {
+		log.Fatalf("Invalid CSP_SOURCES: %s\n", err)
+	}
+	return SecurityHeadersConfig{
+		HstsMaxAge:     hstsMaxAge,
+		FrameOptions:   LookupEnv("FRAME_OPTIONS", "DENY"),
+		ReferrerPolicy: LookupEnv("REFERRER_POLICY", "strict-origin-when-cross-origin"),
+		CspSources:     sources,
+		CspReportOnly:  LookupEnv("CSP_REPORT_ONLY", "false") == "true",
+	}
+}
+
+// addCspSources adds the sources of a policy like `img-src https://a.test; connect-src https://b.test` to sources
+func addCspSources(sources map[string][]string, policy string) error {
+	for _, directive := range strings.Split(policy, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) == 1 {
+			return fmt.Errorf("directive `%s` has no sources", fields[0])
+		}
+		name := strings.ToLower(fields[0])
+		for _, source := range fields[1:] {
+			if !slices.Contains(sources[name], source) {
+				sources[name] = append(sources[name], source)
+			}
+		}
+	}
+	return nil
+}
+
+// contentSecurityPolicy returns the policy for a response with nonce
+func (config SecurityHeadersConfig) contentSecurityPolicy(nonce string) string {
+	directives := slices.Clone(cspDirectives)
+	for name := range config.CspSources {
+		if !slices.Contains(directives, name) {
+			directives = append(directives, name)
+		}
+	}
+	policy := make([]string, 0, len(directives)+2)
+	for _, name := range directives {
+		sources := config.CspSources[name]
+		if name == "script-src" || name == "style-src" {
+			sources = append(slices.Clip(sources), fmt.Sprintf("'nonce-%s'", nonce))
+		}
+		if len(sources) == 0 {
+			continue
+		}
+		policy = append(policy, fmt.Sprintf("%s %s", name, strings.Join(sources, " ")))
+	}
+	policy = append(policy, "report-uri /csp-report", "report-to csp-endpoint")
+	return strings.Join(policy, "; ")
+}
+
+// securityHeaders sets the headers of config on every response.
+// The nonce of the content security policy is made available to the templates with templ.GetNonce.
+func securityHeaders(config SecurityHeadersConfig) echo.MiddlewareFunc {
+	secure := middleware.SecureWithConfig(middleware.SecureConfig{
+		ContentTypeNosniff:    "nosniff",
+		XFrameOptions:         config.FrameOptions,
+		HSTSMaxAge:            config.HstsMaxAge,
+		HSTSExcludeSubdomains: true,
+		ReferrerPolicy:        config.ReferrerPolicy,
+	})
+	cspHeader := "Content-Security-Policy"
+	if config.CspReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return secure(func(c echo.Context) error {
+			nonce, err := generateNonce()
+			if err != nil {
+				return err
+			}
+			c.SetRequest(c.Request().WithContext(templ.WithNonce(c.Request().Context(), nonce)))
+			c.Response().Header().Set(cspHeader, config.contentSecurityPolicy(nonce))
+			c.Response().Header().Set("Reporting-Endpoints", fmt.Sprintf(`csp-endpoint="%s"`, baseurl.Url("/csp-report", nil)))
+			return next(c)
+		})
+	}
+}
+
+func generateNonce() (string, error) {
+	nonce := make([]byte, 16)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(nonce), nil
+}
+
+// cspViolation is a violation report of the report-uri directive
+type cspViolation struct {
+	DocumentUri        string `json:"document-uri"`
+	EffectiveDirective string `json:"effective-directive"`
+	BlockedUri         string `json:"blocked-uri"`
+	SourceFile         string `json:"source-file"`
+	LineNumber         int    `json:"line-number"`
+	Disposition        string `json:"disposition"`
+}
+
+// cspViolationReport is a violation report of the report-to directive, browsers send them in batches
+type cspViolationReport struct {
+	Type string `json:"type"`
+	Body struct {
+		DocumentUrl        string `json:"documentURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		BlockedUrl         string `json:"blockedURL"`
+		SourceFile         string `json:"sourceFile"`
+		LineNumber         int    `json:"lineNumber"`
+		Disposition        string `json:"disposition"`
+	} `json:"body"`
+}
+
+// maxCspReportSize is the size of the largest violation report that is read
+const maxCspReportSize = 64 * 1024
+
+// HandleCspReport logs the violations of the content security policy that browsers report
+func HandleCspReport() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxCspReportSize))
+		if err != nil {
+			return c.NoContent(400)
+		}
+		var violations []cspViolation
+		if strings.HasPrefix(c.Request().Header.Get("Content-Type"), "application/reports+json") {
+			var reports []cspViolationReport
+			if err := json.Unmarshal(body, &reports); err != nil {
+				return c.NoContent(400)
+			}
+			for _, report := range reports {
+				if report.Type != "csp-violation" {
+					continue
+				}
+				violations = append(violations, cspViolation{
+					DocumentUri:        report.Body.DocumentUrl,
+					EffectiveDirective: report.Body.EffectiveDirective,
+					BlockedUri:         report.Body.BlockedUrl,
+					SourceFile:         report.Body.SourceFile,
+					LineNumber:         report.Body.LineNumber,
+					Disposition:        report.Body.Disposition,
+				})
+			}
+		} else {
+			var report struct {
+				CspReport cspViolation `json:"csp-report"`
+			}
+			if err := json.Unmarshal(body, &report); err != nil {
+				return c.NoContent(400)
+			}
+			violations = append(violations, report.CspReport)
+		}
+		for _, violation := range violations {
+			logger.EchoWarn(c, "Content security policy violation",
+				slog.String("document", violation.DocumentUri),
+				slog.String("directive", violation.EffectiveDirective),
+				slog.String("blocked", violation.BlockedUri),
+				slog.String("source", violation.SourceFile),
+				slog.Int("line", violation.LineNumber),
+				slog.String("disposition", violation.Disposition),
+			)
+		}
+		return c.NoContent(204)
+	}
+}
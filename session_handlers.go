@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/pkg/i18n"
+	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/Kavantix/go-form/templates"
+	"github.com/Kavantix/go-form/templates/components"
+	"github.com/a-h/templ"
+	"github.com/labstack/echo/v4"
+)
+
+func requireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		isAdmin, _ := c.Get("IsAdmin").(bool)
+		if !isAdmin {
+			return template(c, 404, templates.NotFound("/users"))
+		}
+		return next(c)
+	}
+}
+
+func renderAccountSessions(c echo.Context, queries *database.Queries, code int, toasts ...components.ToastConfig) error {
+	sessions, err := queries.GetUserSessions(c.Request().Context(), authenticatedUserId(c))
+	if err != nil {
+		return fmt.Errorf("failed to get sessions: %w", err)
+	}
+	templatesToRender := []templ.Component{
+		templates.AccountSessions(sessions, authenticatedSessionId(c)),
+	}
+	for _, toast := range toasts {
+		templatesToRender = append(templatesToRender, components.Toast(toast))
+	}
+	return template(c, code, templatesToRender...)
+}
+
+func HandleAccountSessions(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return renderAccountSessions(c, queries, 200)
+	}
+}
+
+func HandleRevokeAccountSession(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.String(400, "invalid id")
+		}
+		revoked, err := queries.DeleteUserSession(c.Request().Context(), int32(id), authenticatedUserId(c))
+		if err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+		if revoked == 0 {
+			return template(c, 404, templates.NotFound("/account/sessions"))
+		}
+		logger.EchoInfo(c, "Revoked session", slog.Int("session", id))
+		if !isHtmx(c) {
+			return c.Redirect(303, "/account/sessions")
+		}
+		return renderAccountSessions(c, queries, 200, components.ToastConfig{
+			Message: i18n.T(c.Request().Context(), "Session revoked"),
+			Variant: components.ToastSuccess,
+		})
+	}
+}
+
+func HandleRevokeAccountSessions(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		revoked, err := queries.DeleteUserSessions(c.Request().Context(), authenticatedUserId(c))
+		if err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+		logger.EchoInfo(c, "Signed out everywhere", slog.Int64("sessions", revoked))
+		clearUserLoggedInCookie(c)
+		if !isHtmx(c) {
+			return c.Redirect(303, "/login")
+		}
+		return htmxRedirect(c, "/login")
+	}
+}
+
+func renderAdminSessions(c echo.Context, queries *database.Queries, toasts ...components.ToastConfig) error {
+	page, pageSize, err := paginationParams(c)
+	if err != nil {
+		return c.String(400, "invalid pagination")
+	}
+	sessions, err := queries.GetSessionsPage(c.Request().Context(), page, pageSize)
+	if err != nil {
+		return fmt.Errorf("failed to get sessions: %w", err)
+	}
+	templatesToRender := []templ.Component{
+		templates.AdminSessions(sessions),
+	}
+	for _, toast := range toasts {
+		templatesToRender = append(templatesToRender, components.Toast(toast))
+	}
+	return template(c, 200, templatesToRender...)
+}
+
+func HandleAdminSessions(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return renderAdminSessions(c, queries)
+	}
+}
+
+func HandleAdminRevokeSession(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.String(400, "invalid id")
+		}
+		revoked, err := queries.DeleteSession(c.Request().Context(), int32(id))
+		if err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+		if revoked == 0 {
+			return template(c, 404, templates.NotFound("/admin/sessions"))
+		}
+		logger.EchoInfo(c, "Admin revoked session", slog.Int("session", id))
+		if !isHtmx(c) {
+			return c.Redirect(303, "/admin/sessions")
+		}
+		return renderAdminSessions(c, queries, components.ToastConfig{
+			Message: i18n.T(c.Request().Context(), "Session revoked"),
+			Variant: components.ToastSuccess,
+		})
+	}
+}
+
+func HandleAdminRevokeUserSessions(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return c.String(400, "invalid id")
+		}
+		revoked, err := queries.DeleteUserSessions(c.Request().Context(), int32(userId))
+		if err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+		logger.EchoInfo(c, "Admin revoked sessions of user", slog.Int("user", userId), slog.Int64("sessions", revoked))
+		if !isHtmx(c) {
+			return c.Redirect(303, "/admin/sessions")
+		}
+		return renderAdminSessions(c, queries, components.ToastConfig{
+			Message: i18n.T(c.Request().Context(), "Revoked %d sessions", revoked),
+			Variant: components.ToastSuccess,
+		})
+	}
+}
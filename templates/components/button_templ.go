@@ -88,7 +88,7 @@ func Button(config ButtonConfig) templ.Component {
 				return templ_7745c5c3_Err
 			}
 			if !config.NotReversible {
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(" hx-trigger=\"mousedown[event.button == 0 &amp;&amp; !event.metaKey]\" onclick=\"event.metaKey || event.preventDefault()\"")
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(" hx-trigger=\"mousedown[event.button == 0 &amp;&amp; !event.metaKey]\" data-prevent-click")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
@@ -147,7 +147,7 @@ func Button(config ButtonConfig) templ.Component {
 					return templ_7745c5c3_Err
 				}
 				if !config.NotReversible {
-					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(" hx-trigger=\"mousedown[event.button == 0]\" onclick=\"event.metaKey\"")
+					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(" hx-trigger=\"mousedown[event.button == 0]\"")
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
@@ -0,0 +1,140 @@
+// Code generated by templ - DO NOT EDIT.
+
+// templ: version: v0.2.747
+package components
+
+//lint:file-ignore SA4006 This context is only used if a nested component is present.
+
+import "github.com/a-h/templ"
+import templruntime "github.com/a-h/templ/runtime"
+
+import (
+	"context"
+	. "github.com/Kavantix/go-form/interfaces"
+	"github.com/Kavantix/go-form/pkg/i18n"
+	"time"
+)
+
+const dateFormat = "2006-01-02"
+
+type DateFormFieldConfig[T any] struct {
+	FieldLabel     string
+	FieldName      string
+	Placeholder    string
+	Required       bool
+	FieldValue     func(row *T) time.Time
+	FieldSetter    func(row *T, value time.Time)
+	FieldValidator func(value time.Time) string
+}
+
+var _ FormField[any] = &DateFormFieldConfig[any]{}
+
+func DateFormField[T any](form FormConfig[T], config *DateFormFieldConfig[T], value *T, validationError string) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var1 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var1 == nil {
+			templ_7745c5c3_Var1 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Var2 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+			if !templ_7745c5c3_IsBuffer {
+				defer func() {
+					templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+					if templ_7745c5c3_Err == nil {
+						templ_7745c5c3_Err = templ_7745c5c3_BufErr
+					}
+				}()
+			}
+			ctx = templ.InitializeContext(ctx)
+			templ_7745c5c3_Err = TextField(
+				config.Required,
+				"date",
+				config.FieldName,
+				i18n.T(ctx, config.Placeholder),
+				config.Value(value),
+			).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			return templ_7745c5c3_Err
+		})
+		templ_7745c5c3_Err = formField(config, validationError).Render(templ.WithChildren(ctx, templ_7745c5c3_Var2), templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return templ_7745c5c3_Err
+	})
+}
+
+func (f *DateFormFieldConfig[T]) RenderFormField(form FormConfig[T], value *T, validationErrors map[string]string) templ.Component {
+	return DateFormField(form, f, value, validationErrors[f.FieldName])
+}
+
+func (f *DateFormFieldConfig[T]) Name() string {
+	return f.FieldName
+}
+
+func (f *DateFormFieldConfig[T]) Validator(ctx context.Context, value string) string {
+	if value == "" {
+		if f.Required {
+			return i18n.T(ctx, "This field is required")
+		}
+		return ""
+	}
+	date, err := time.Parse(dateFormat, value)
+	if err != nil || f.FieldValidator == nil {
+		// Invalid dates are reported when binding
+		return ""
+	}
+	return i18n.T(ctx, f.FieldValidator(date))
+}
+
+func (f *DateFormFieldConfig[T]) Label() string {
+	if f.Required {
+		return f.FieldLabel + "*"
+	}
+	return f.FieldLabel
+}
+
+func (f *DateFormFieldConfig[T]) Value(row *T) string {
+	if row == nil {
+		return ""
+	}
+	date := f.FieldValue(row)
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(dateFormat)
+}
+
+func (f *DateFormFieldConfig[T]) Bind(row *T, formFields map[string]string) error {
+	value := formFields[f.FieldName]
+	if value == "" {
+		return nil
+	}
+	date, err := time.Parse(dateFormat, value)
+	if err != nil {
+		return ParsingError{
+			FieldName: f.FieldName,
+			Reason:    err,
+			Message:   "Invalid date",
+		}
+	}
+	if f.FieldSetter != nil {
+		f.FieldSetter(row, date)
+	}
+	return nil
+}
@@ -8,11 +8,13 @@ package components
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
+import "context"
 import "fmt"
 import "strings"
 import . "github.com/Kavantix/go-form/interfaces"
+import "github.com/Kavantix/go-form/pkg/i18n"
 
-func formField[T any](config FormField[T], opts ...formFieldOption) templ.Component {
+func formField[T any](config FormField[T], validationError string, opts ...formFieldOption) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
@@ -37,7 +39,7 @@ func formField[T any](config FormField[T], opts ...formFieldOption) templ.Compon
 		var templ_7745c5c3_Var2 string
 		templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf(`formField("%s", %s)`, config.Name(), buildFormFieldOptions(opts)))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/form_field.templ`, Line: 9, Col: 89}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/form_field.templ`, Line: 11, Col: 89}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
 		if templ_7745c5c3_Err != nil {
@@ -48,9 +50,9 @@ func formField[T any](config FormField[T], opts ...formFieldOption) templ.Compon
 			return templ_7745c5c3_Err
 		}
 		var templ_7745c5c3_Var3 string
-		templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(config.Label())
+		templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(translateLabel(ctx, config.Label()))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/form_field.templ`, Line: 15, Col: 20}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/form_field.templ`, Line: 17, Col: 41}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
 		if templ_7745c5c3_Err != nil {
@@ -64,7 +66,30 @@ func formField[T any](config FormField[T], opts ...formFieldOption) templ.Compon
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<template x-if=\"!valid\"><p :id=\"errorId\" aria-live=\"true\" class=\"mt-2 text-sm text-red-600 dark:text-red-500\" x-text=\"error\"></p></template></div>")
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<p :id=\"errorId\" aria-live=\"true\" class=\"mt-2 text-sm text-red-600 dark:text-red-500\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if validationError == "" {
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(" style=\"display: none\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(" x-show=\"!valid\" x-text=\"error\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var4 string
+		templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(validationError)
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/form_field.templ`, Line: 31, Col: 20}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</p></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -72,6 +97,14 @@ func formField[T any](config FormField[T], opts ...formFieldOption) templ.Compon
 	})
 }
 
+// translateLabel translates the label of a field to the locale of ctx, keeping the marker of required fields
+func translateLabel(ctx context.Context, label string) string {
+	if label, required := strings.CutSuffix(label, "*"); required {
+		return i18n.T(ctx, label) + "*"
+	}
+	return i18n.T(ctx, label)
+}
+
 type formFieldOption interface {
 	formFieldOption()
 }
@@ -9,8 +9,9 @@ import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
 import (
-	"fmt"
+	"context"
 	. "github.com/Kavantix/go-form/interfaces"
+	"github.com/Kavantix/go-form/pkg/i18n"
 )
 
 type SelectFormFieldConfig[T any] struct {
@@ -20,11 +21,12 @@ type SelectFormFieldConfig[T any] struct {
 	Options     []struct{ Label, Value string }
 	Required    bool
 	FieldValue  func(row *T) string
+	FieldSetter func(row *T, value string)
 }
 
 var _ FormField[any] = &SelectFormFieldConfig[any]{}
 
-func SelectFormField[T any](config *SelectFormFieldConfig[T], value string) templ.Component {
+func SelectFormField[T any](config *SelectFormFieldConfig[T], value string, validationError string) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
@@ -54,7 +56,20 @@ func SelectFormField[T any](config *SelectFormFieldConfig[T], value string) temp
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<select x-bind=\"input\"")
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<select x-bind=\"input\" name=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var3 string
+			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(config.FieldName)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/select_form_field.templ`, Line: 25, Col: 26}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\"")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -68,12 +83,12 @@ func SelectFormField[T any](config *SelectFormFieldConfig[T], value string) temp
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var3 string
-			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(config.Placeholder)
+			var templ_7745c5c3_Var4 string
+			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(i18n.T(ctx, config.Placeholder))
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/select_form_field.templ`, Line: 25, Col: 35}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/select_form_field.templ`, Line: 28, Col: 48}
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -118,12 +133,12 @@ func SelectFormField[T any](config *SelectFormFieldConfig[T], value string) temp
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				var templ_7745c5c3_Var4 string
-				templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(option.Value)
+				var templ_7745c5c3_Var5 string
+				templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(option.Value)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/select_form_field.templ`, Line: 32, Col: 68}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/select_form_field.templ`, Line: 35, Col: 68}
 				}
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
@@ -131,12 +146,12 @@ func SelectFormField[T any](config *SelectFormFieldConfig[T], value string) temp
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				var templ_7745c5c3_Var5 string
-				templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(option.Label)
+				var templ_7745c5c3_Var6 string
+				templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(i18n.T(ctx, option.Label))
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/select_form_field.templ`, Line: 32, Col: 85}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/select_form_field.templ`, Line: 35, Col: 98}
 				}
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
@@ -151,7 +166,7 @@ func SelectFormField[T any](config *SelectFormFieldConfig[T], value string) temp
 			}
 			return templ_7745c5c3_Err
 		})
-		templ_7745c5c3_Err = formField(config, formFieldDebounce{Millis: 20}).Render(templ.WithChildren(ctx, templ_7745c5c3_Var2), templ_7745c5c3_Buffer)
+		templ_7745c5c3_Err = formField(config, validationError, formFieldDebounce{Millis: 20}).Render(templ.WithChildren(ctx, templ_7745c5c3_Var2), templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -159,22 +174,22 @@ func SelectFormField[T any](config *SelectFormFieldConfig[T], value string) temp
 	})
 }
 
-func (f *SelectFormFieldConfig[T]) RenderFormField(form FormConfig[T], value *T) templ.Component {
+func (f *SelectFormFieldConfig[T]) RenderFormField(form FormConfig[T], value *T, validationErrors map[string]string) templ.Component {
 	val := ""
 	if value != nil {
 		val = f.Value(value)
 	}
-	return SelectFormField(f, val)
+	return SelectFormField(f, val, validationErrors[f.FieldName])
 }
 
 func (f *SelectFormFieldConfig[T]) Name() string {
 	return f.FieldName
 }
 
-func (f *SelectFormFieldConfig[T]) Validator(value string) string {
+func (f *SelectFormFieldConfig[T]) Validator(ctx context.Context, value string) string {
 	if value == "" {
 		if f.Required {
-			return fmt.Sprintf("This field is required")
+			return i18n.T(ctx, "This field is required")
 		}
 		return ""
 	}
@@ -183,7 +198,7 @@ func (f *SelectFormFieldConfig[T]) Validator(value string) string {
 			return ""
 		}
 	}
-	return fmt.Sprintf("`%s` is not a valid option", value)
+	return i18n.T(ctx, "`%s` is not a valid option", value)
 }
 
 func (f *SelectFormFieldConfig[T]) Label() string {
@@ -199,3 +214,10 @@ func (f *SelectFormFieldConfig[T]) Value(row *T) string {
 	}
 	return f.FieldValue(row)
 }
+
+func (f *SelectFormFieldConfig[T]) Bind(row *T, formFields map[string]string) error {
+	if f.FieldSetter != nil {
+		f.FieldSetter(row, formFields[f.FieldName])
+	}
+	return nil
+}
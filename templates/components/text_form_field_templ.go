@@ -8,7 +8,11 @@ package components
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
-import . "github.com/Kavantix/go-form/interfaces"
+import (
+	"context"
+	. "github.com/Kavantix/go-form/interfaces"
+	"github.com/Kavantix/go-form/pkg/i18n"
+)
 
 type TextFormFieldConfig[T any] struct {
 	FieldLabel     string
@@ -17,6 +21,7 @@ type TextFormFieldConfig[T any] struct {
 	Type           string
 	Required       bool
 	FieldValue     func(row *T) string
+	FieldSetter    func(row *T, value string)
 	FieldValidator func(value string) string
 }
 
@@ -69,7 +74,7 @@ func TextField(required bool, fieldType, name, placeholder, value string) templ.
 			var templ_7745c5c3_Var2 string
 			templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(fieldType)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/text_form_field.templ`, Line: 25, Col: 19}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/text_form_field.templ`, Line: 30, Col: 19}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
 			if templ_7745c5c3_Err != nil {
@@ -88,7 +93,7 @@ func TextField(required bool, fieldType, name, placeholder, value string) templ.
 			var templ_7745c5c3_Var3 string
 			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(name)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/text_form_field.templ`, Line: 28, Col: 14}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/text_form_field.templ`, Line: 33, Col: 14}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
 			if templ_7745c5c3_Err != nil {
@@ -107,7 +112,7 @@ func TextField(required bool, fieldType, name, placeholder, value string) templ.
 			var templ_7745c5c3_Var4 string
 			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(value)
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/text_form_field.templ`, Line: 31, Col: 16}
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/text_form_field.templ`, Line: 36, Col: 16}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
 			if templ_7745c5c3_Err != nil {
@@ -125,7 +130,7 @@ func TextField(required bool, fieldType, name, placeholder, value string) templ.
 		var templ_7745c5c3_Var5 string
 		templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(placeholder)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/text_form_field.templ`, Line: 35, Col: 27}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `templates/components/text_form_field.templ`, Line: 40, Col: 27}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
 		if templ_7745c5c3_Err != nil {
@@ -139,7 +144,7 @@ func TextField(required bool, fieldType, name, placeholder, value string) templ.
 	})
 }
 
-func TextFormField[T any](form FormConfig[T], config *TextFormFieldConfig[T], value *T) templ.Component {
+func TextFormField[T any](form FormConfig[T], config *TextFormFieldConfig[T], value *T, validationError string) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
@@ -172,8 +177,8 @@ func TextFormField[T any](form FormConfig[T], config *TextFormFieldConfig[T], va
 			templ_7745c5c3_Err = TextField(
 				config.Required,
 				config.Type,
-				"",
-				config.Placeholder,
+				config.FieldName,
+				i18n.T(ctx, config.Placeholder),
 				config.Value(value),
 			).Render(ctx, templ_7745c5c3_Buffer)
 			if templ_7745c5c3_Err != nil {
@@ -181,7 +186,7 @@ func TextFormField[T any](form FormConfig[T], config *TextFormFieldConfig[T], va
 			}
 			return templ_7745c5c3_Err
 		})
-		templ_7745c5c3_Err = formField(config).Render(templ.WithChildren(ctx, templ_7745c5c3_Var7), templ_7745c5c3_Buffer)
+		templ_7745c5c3_Err = formField(config, validationError).Render(templ.WithChildren(ctx, templ_7745c5c3_Var7), templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -189,19 +194,19 @@ func TextFormField[T any](form FormConfig[T], config *TextFormFieldConfig[T], va
 	})
 }
 
-func (f *TextFormFieldConfig[T]) RenderFormField(form FormConfig[T], value *T) templ.Component {
-	return TextFormField(form, f, value)
+func (f *TextFormFieldConfig[T]) RenderFormField(form FormConfig[T], value *T, validationErrors map[string]string) templ.Component {
+	return TextFormField(form, f, value, validationErrors[f.FieldName])
 }
 
 func (f *TextFormFieldConfig[T]) Name() string {
 	return f.FieldName
 }
 
-func (f *TextFormFieldConfig[T]) Validator(value string) string {
+func (f *TextFormFieldConfig[T]) Validator(ctx context.Context, value string) string {
 	if f.FieldValidator == nil {
 		return ""
 	}
-	return f.FieldValidator(value)
+	return i18n.T(ctx, f.FieldValidator(value))
 }
 
 func (f *TextFormFieldConfig[T]) Label() string {
@@ -217,3 +222,10 @@ func (f *TextFormFieldConfig[T]) Value(row *T) string {
 	}
 	return f.FieldValue(row)
 }
+
+func (f *TextFormFieldConfig[T]) Bind(row *T, formFields map[string]string) error {
+	if f.FieldSetter != nil {
+		f.FieldSetter(row, formFields[f.FieldName])
+	}
+	return nil
+}
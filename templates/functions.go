@@ -2,7 +2,11 @@ package templates
 
 import (
 	"context"
+	"encoding/json"
 	"net/url"
+
+	"github.com/Kavantix/go-form/pkg/i18n"
+	"github.com/a-h/templ"
 )
 
 func IsHtmx(ctx context.Context) bool {
@@ -10,7 +14,49 @@ func IsHtmx(ctx context.Context) bool {
 	return ok && isHtmx
 }
 
+// IsAdmin reports whether the user that is logged in is an admin
+func IsAdmin(ctx context.Context) bool {
+	isAdmin, ok := ctx.Value("isAdmin").(bool)
+	return ok && isAdmin
+}
+
 func CurrentUrl(ctx context.Context) *url.URL {
 	result, _ := ctx.Value("currentUrl").(*url.URL)
 	return result
 }
+
+// ImpersonatedEmail returns the email of the user an admin acts as, or an empty string when not impersonating
+func ImpersonatedEmail(ctx context.Context) string {
+	email, _ := ctx.Value("impersonatedEmail").(string)
+	return email
+}
+
+// htmxConfig returns the htmx config with the nonce of the content security policy,
+// htmx adds it to the scripts and styles that it inserts
+func htmxConfig(ctx context.Context) string {
+	nonce := templ.GetNonce(ctx)
+	config, _ := json.Marshal(map[string]any{
+		"inlineScriptNonce": nonce,
+		"inlineStyleNonce":  nonce,
+	})
+	return string(config)
+}
+
+// scriptMessages are the messages that are shown by public/js/app.js
+var scriptMessages = []string{
+	"Your browser does not support passkeys",
+	"Something went wrong, please try again",
+	"Logging in with a passkey was cancelled or failed",
+	"Adding the passkey was cancelled or failed",
+}
+
+// scriptTranslations returns the translations of scriptMessages to the locale of ctx,
+// the scripts look them up with their English message
+func scriptTranslations(ctx context.Context) string {
+	translations := map[string]string{}
+	for _, message := range scriptMessages {
+		translations[message] = i18n.T(ctx, message)
+	}
+	result, _ := json.Marshal(translations)
+	return string(result)
+}
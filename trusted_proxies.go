@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ResolveIPExtractor returns how the ip address of clients is determined,
+// trustedProxies is a comma separated list of the ip ranges of the proxies in front of the server.
+// The X-Forwarded-For header is only used for requests from those proxies,
+// without proxies it is ignored so clients cannot pick their own address.
+func ResolveIPExtractor(trustedProxies string) (echo.IPExtractor, error) {
+	if trustedProxies == "" {
+		return echo.ExtractIPDirect(), nil
+	}
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range strings.Split(trustedProxies, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			// A single address
+			if strings.Contains(proxy, ":") {
+				proxy += "/128"
+			} else {
+				proxy += "/32"
+			}
+		}
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy `%s`: %w", proxy, err)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+// clientIp returns the ip address of the client with the IPExtractor of the server,
+// without one the address of the connection is used instead of trusting X-Forwarded-For like RealIP does.
+func clientIp(c echo.Context) string {
+	extractor := c.Echo().IPExtractor
+	if extractor == nil {
+		extractor = echo.ExtractIPDirect()
+	}
+	return extractor(c.Request())
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Kavantix/go-form/auth"
+	"github.com/Kavantix/go-form/database"
+	"github.com/Kavantix/go-form/pkg/i18n"
+	"github.com/Kavantix/go-form/pkg/logger"
+	"github.com/Kavantix/go-form/templates"
+	"github.com/Kavantix/go-form/templates/components"
+	"github.com/a-h/templ"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// twoFactorPendingValidFor is how long the second step of a login can be completed after using the login link
+	twoFactorPendingValidFor = time.Minute * 5
+	// recoveryCodeCount is the number of recovery codes that are generated at once
+	recoveryCodeCount = 10
+)
+
+// userHasTwoFactor reports whether the user has confirmed a TOTP secret
+func userHasTwoFactor(ctx context.Context, queries *database.Queries, userId int32) (bool, error) {
+	userTotp, err := queries.GetUserTotp(ctx, userId)
+	if errors.Is(err, database.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return userTotp.ConfirmedAt.Valid, nil
+}
+
+// verifyTwoFactorCode checks code against the TOTP secret of the user,
+// codes that do not match are tried as recovery code which is then used up.
+func verifyTwoFactorCode(c echo.Context, queries *database.Queries, userId int32, code string) (bool, error) {
+	if code == "" {
+		return false, nil
+	}
+	userTotp, err := queries.GetUserTotp(c.Request().Context(), userId)
+	if errors.Is(err, database.ErrNotFound) || (err == nil && !userTotp.ConfirmedAt.Valid) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to get totp secret: %w", err)
+	}
+	secret, err := auth.DecryptTotpSecret(userTotp.SecretEncrypted)
+	if err != nil {
+		return false, err
+	}
+	if step, ok := auth.ValidateTotp(code, secret, userTotp.LastUsedStep); ok {
+		// The step is saved so the code cannot be used again
+		used, err := queries.UseTotpStep(c.Request().Context(), userId, step)
+		if err != nil {
+			return false, fmt.Errorf("failed to save totp step: %w", err)
+		}
+		return used > 0, nil
+	}
+	consumed, err := queries.ConsumeRecoveryCode(c.Request().Context(), userId, auth.HashRecoveryCode(code))
+	if err != nil {
+		return false, fmt.Errorf("failed to consume recovery code: %w", err)
+	}
+	if consumed > 0 {
+		logger.EchoInfo(c, "Used recovery code", slog.Int("user", int(userId)))
+		return true, nil
+	}
+	return false, nil
+}
+
+func setTwoFactorPendingCookie(c echo.Context, userId int32, isProduction bool) error {
+	token, err := auth.CreateJwt(&auth.JwtOptions{
+		Subject:  strconv.Itoa(int(userId)),
+		Audience: "2fa",
+		ValidFor: twoFactorPendingValidFor,
+	})
+	if err != nil {
+		return err
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     "goform_2fa",
+		Value:    token,
+		Path:     "/login/2fa",
+		MaxAge:   int(twoFactorPendingValidFor.Seconds()),
+		Secure:   isProduction,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+func clearTwoFactorPendingCookie(c echo.Context, isProduction bool) {
+	c.SetCookie(&http.Cookie{
+		Name:     "goform_2fa",
+		Value:    "",
+		Path:     "/login/2fa",
+		MaxAge:   -1,
+		Secure:   isProduction,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// tryGetTwoFactorPendingUserId returns the user that used a login link but still has to enter a second factor
+func tryGetTwoFactorPendingUserId(c echo.Context) (int32, error) {
+	cookie, err := c.Cookie("goform_2fa")
+	if err != nil {
+		return 0, err
+	}
+	claims, err := auth.ParseJwt(cookie.Value)
+	if err != nil {
+		return 0, err
+	}
+	if claims["aud"] != "2fa" {
+		return 0, fmt.Errorf("invalid audience")
+	}
+	rawUserId, _ := claims["sub"].(string)
+	userId, err := strconv.Atoi(rawUserId)
+	if err != nil {
+		return 0, err
+	}
+	return int32(userId), nil
+}
+
+func HandleTwoFactorChallenge() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		_, err := tryGetTwoFactorPendingUserId(c)
+		if err != nil {
+			return c.Redirect(302, "/login")
+		}
+		return template(c, 200, templates.TwoFactorChallenge(""))
+	}
+}
+
+func HandlePostTwoFactorChallenge(isProduction bool, queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId, err := tryGetTwoFactorPendingUserId(c)
+		if err != nil {
+			return c.Redirect(303, "/login")
+		}
+		valid, err := verifyTwoFactorCode(c, queries, userId, c.FormValue("code"))
+		if err != nil {
+			return err
+		}
+		if !valid {
+			logger.EchoInfo(c, "Invalid two-factor code", slog.Int("user", int(userId)))
+			return template(c, 422, templates.TwoFactorChallenge(i18n.T(c.Request().Context(), "The code is invalid, please try again.")))
+		}
+		clearTwoFactorPendingCookie(c, isProduction)
+		err = setUserLoggedInCookie(c, queries, userId, isProduction)
+		if err != nil {
+			return fmt.Errorf("Failed to create token: %w", err)
+		}
+		return c.Redirect(303, "/users")
+	}
+}
+
+func renderAccountTwoFactor(c echo.Context, queries *database.Queries, code int, state templates.AccountTwoFactorState, toasts ...components.ToastConfig) error {
+	userId := authenticatedUserId(c)
+	enabled, err := userHasTwoFactor(c.Request().Context(), queries, userId)
+	if err != nil {
+		return fmt.Errorf("failed to get totp secret: %w", err)
+	}
+	state.Enabled = enabled
+	state.Required, _ = c.Get("RequireTwoFactor").(bool)
+	if enabled {
+		state.RemainingRecoveryCodes, err = queries.CountUnusedRecoveryCodes(c.Request().Context(), userId)
+		if err != nil {
+			return fmt.Errorf("failed to count recovery codes: %w", err)
+		}
+	}
+	templatesToRender := []templ.Component{
+		templates.AccountTwoFactor(state),
+	}
+	for _, toast := range toasts {
+		templatesToRender = append(templatesToRender, components.Toast(toast))
+	}
+	return template(c, code, templatesToRender...)
+}
+
+// renderTwoFactorSetup renders the qr code of the unconfirmed secret of the user
+func renderTwoFactorSetup(c echo.Context, queries *database.Queries, code int, email, secret, validationError string) error {
+	key, err := auth.TotpKey(email, secret)
+	if err != nil {
+		return err
+	}
+	qrCode, err := auth.TotpQrCode(key)
+	if err != nil {
+		return err
+	}
+	return renderAccountTwoFactor(c, queries, code, templates.AccountTwoFactorState{
+		SetupQrCode: qrCode,
+		SetupSecret: key.Secret(),
+		Error:       validationError,
+	})
+}
+
+func HandleAccountTwoFactor(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return renderAccountTwoFactor(c, queries, 200, templates.AccountTwoFactorState{})
+	}
+}
+
+func HandleSetupTwoFactor(queries *database.Queries, getUser GetUserFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUser(c)
+		if err != nil {
+			return err
+		}
+		enabled, err := userHasTwoFactor(c.Request().Context(), queries, user.Id)
+		if err != nil {
+			return fmt.Errorf("failed to get totp secret: %w", err)
+		}
+		if enabled {
+			return renderAccountTwoFactor(c, queries, 422, templates.AccountTwoFactorState{
+				Error: i18n.T(c.Request().Context(), "Two-factor authentication is already enabled."),
+			})
+		}
+		key, err := auth.GenerateTotp(user.Email)
+		if err != nil {
+			return fmt.Errorf("failed to generate totp secret: %w", err)
+		}
+		encryptedSecret, err := auth.EncryptTotpSecret(key.Secret())
+		if err != nil {
+			return fmt.Errorf("failed to encrypt totp secret: %w", err)
+		}
+		err = queries.UpsertUserTotp(c.Request().Context(), user.Id, encryptedSecret)
+		if err != nil {
+			return fmt.Errorf("failed to save totp secret: %w", err)
+		}
+		return renderTwoFactorSetup(c, queries, 200, user.Email, key.Secret(), "")
+	}
+}
+
+func HandleConfirmTwoFactor(queries *database.Queries, getUser GetUserFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, err := getUser(c)
+		if err != nil {
+			return err
+		}
+		userTotp, err := queries.GetUserTotp(c.Request().Context(), user.Id)
+		if errors.Is(err, database.ErrNotFound) || (err == nil && userTotp.ConfirmedAt.Valid) {
+			if !isHtmx(c) {
+				return c.Redirect(303, "/account/2fa")
+			}
+			return renderAccountTwoFactor(c, queries, 200, templates.AccountTwoFactorState{})
+		} else if err != nil {
+			return fmt.Errorf("failed to get totp secret: %w", err)
+		}
+		secret, err := auth.DecryptTotpSecret(userTotp.SecretEncrypted)
+		if err != nil {
+			return err
+		}
+		step, ok := auth.ValidateTotp(c.FormValue("code"), secret, userTotp.LastUsedStep)
+		if !ok {
+			return renderTwoFactorSetup(c, queries, 422, user.Email, secret, i18n.T(c.Request().Context(), "The code is invalid, please try again."))
+		}
+		// The code that confirmed the secret cannot be used to log in
+		_, err = queries.UseTotpStep(c.Request().Context(), user.Id, step)
+		if err != nil {
+			return fmt.Errorf("failed to save totp step: %w", err)
+		}
+		_, err = queries.ConfirmUserTotp(c.Request().Context(), user.Id)
+		if err != nil {
+			return fmt.Errorf("failed to confirm totp secret: %w", err)
+		}
+		codes, hashes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+		if err != nil {
+			return err
+		}
+		err = queries.ReplaceRecoveryCodes(c.Request().Context(), user.Id, hashes)
+		if err != nil {
+			return fmt.Errorf("failed to save recovery codes: %w", err)
+		}
+		logger.EchoInfo(c, "Enabled two-factor authentication", slog.Int("user", int(user.Id)))
+		// The recovery codes are only rendered here, so non-js requests are not redirected
+		return renderAccountTwoFactor(c, queries, 200, templates.AccountTwoFactorState{
+			RecoveryCodes: codes,
+		}, components.ToastConfig{
+			Message: i18n.T(c.Request().Context(), "Two-factor authentication enabled"),
+			Variant: components.ToastSuccess,
+		})
+	}
+}
+
+func HandleRegenerateRecoveryCodes(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := authenticatedUserId(c)
+		valid, err := verifyTwoFactorCode(c, queries, userId, c.FormValue("code"))
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return renderAccountTwoFactor(c, queries, 422, templates.AccountTwoFactorState{
+				Error: i18n.T(c.Request().Context(), "The code is invalid, please try again."),
+			})
+		}
+		codes, hashes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+		if err != nil {
+			return err
+		}
+		err = queries.ReplaceRecoveryCodes(c.Request().Context(), userId, hashes)
+		if err != nil {
+			return fmt.Errorf("failed to save recovery codes: %w", err)
+		}
+		logger.EchoInfo(c, "Regenerated recovery codes", slog.Int("user", int(userId)))
+		return renderAccountTwoFactor(c, queries, 200, templates.AccountTwoFactorState{
+			RecoveryCodes: codes,
+		})
+	}
+}
+
+func HandleDisableTwoFactor(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userId := authenticatedUserId(c)
+		if required, _ := c.Get("RequireTwoFactor").(bool); required {
+			return renderAccountTwoFactor(c, queries, 422, templates.AccountTwoFactorState{
+				Error: i18n.T(c.Request().Context(), "Your role requires two-factor authentication."),
+			})
+		}
+		valid, err := verifyTwoFactorCode(c, queries, userId, c.FormValue("code"))
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return renderAccountTwoFactor(c, queries, 422, templates.AccountTwoFactorState{
+				Error: i18n.T(c.Request().Context(), "The code is invalid, please try again."),
+			})
+		}
+		err = queries.DeleteUserTotp(c.Request().Context(), userId)
+		if err != nil {
+			return fmt.Errorf("failed to delete totp secret: %w", err)
+		}
+		err = queries.DeleteRecoveryCodes(c.Request().Context(), userId)
+		if err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+		logger.EchoInfo(c, "Disabled two-factor authentication", slog.Int("user", int(userId)))
+		if !isHtmx(c) {
+			return c.Redirect(303, "/account/2fa")
+		}
+		return renderAccountTwoFactor(c, queries, 200, templates.AccountTwoFactorState{}, components.ToastConfig{
+			Message: i18n.T(c.Request().Context(), "Two-factor authentication disabled"),
+			Variant: components.ToastSuccess,
+		})
+	}
+}
+
+// roleSettings returns the settings of all roles, including the roles that were never saved
+func roleSettings(ctx context.Context, queries *database.Queries) ([]database.RoleSetting, error) {
+	saved, err := queries.GetRoleSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role settings: %w", err)
+	}
+	settings := []database.RoleSetting{}
+	for _, role := range database.Roles {
+		setting := database.RoleSetting{Role: role}
+		for _, savedSetting := range saved {
+			if savedSetting.Role == role {
+				setting = savedSetting
+			}
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+func renderAdminRoles(c echo.Context, queries *database.Queries, toasts ...components.ToastConfig) error {
+	settings, err := roleSettings(c.Request().Context(), queries)
+	if err != nil {
+		return err
+	}
+	templatesToRender := []templ.Component{
+		templates.AdminRoles(settings),
+	}
+	for _, toast := range toasts {
+		templatesToRender = append(templatesToRender, components.Toast(toast))
+	}
+	return template(c, 200, templatesToRender...)
+}
+
+func HandleAdminRoles(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return renderAdminRoles(c, queries)
+	}
+}
+
+func HandlePostAdminRoles(queries *database.Queries) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		values, err := c.FormParams()
+		if err != nil {
+			return c.String(400, "invalid form")
+		}
+		requiredRoles := values["require_two_factor"]
+		for _, role := range database.Roles {
+			err = queries.UpsertRoleSetting(c.Request().Context(), role, slices.Contains(requiredRoles, role))
+			if err != nil {
+				return fmt.Errorf("failed to save role setting: %w", err)
+			}
+		}
+		logger.EchoInfo(c, "Admin updated role settings", slog.Any("require_two_factor", requiredRoles))
+		if !isHtmx(c) {
+			return c.Redirect(303, "/admin/roles")
+		}
+		return renderAdminRoles(c, queries, components.ToastConfig{
+			Message: i18n.T(c.Request().Context(), "Roles saved"),
+			Variant: components.ToastSuccess,
+		})
+	}
+}